@@ -3,9 +3,14 @@
 package main
 
 import (
+	"os"
+
 	"github.com/20xygen/git-blame/internal/cli"
+	"github.com/20xygen/git-blame/internal/utils"
 )
 
 func main() {
-	_ = cli.Execute()
+	if err := cli.Execute(); err != nil {
+		os.Exit(utils.CodeParametersParsing)
+	}
 }
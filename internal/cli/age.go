@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+)
+
+var ageCmd = &cobra.Command{
+	Use:   "age",
+	Short: "Report code age distribution",
+	Long:  "Age buckets surviving lines by the age of their commits relative to the revision, per author, per directory and repository-wide.",
+	Args:  cobra.NoArgs,
+	Run:   ageCommand,
+}
+
+func ageCommand(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+
+	bounds, e1 := cmd.Flags().GetStringSlice("buckets")
+	depth, e2 := cmd.Flags().GetInt("depth")
+	if utils.AnyError(e1, e2) {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+	if depth < 0 {
+		fail(utils.ErrorInvalidParameters{Info: "depth must not be negative"}, utils.CodeParametersParsing)
+	}
+
+	buckets, err := statistics.ParseBuckets(bounds)
+	if err != nil {
+		fail(err, utils.CodeParametersParsing)
+	}
+
+	st := collectStat(cmd, ps, info)
+
+	output, err := format.RenderTable(format.AgeTable(st, buckets, depth), ps.Format)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	ageCmd.Flags().StringSlice("buckets", []string{"1m", "6m", "1y", "2y"}, "Ascending age bounds with units 'd', 'w', 'm' or 'y'")
+	ageCmd.Flags().Int("depth", 1, "Directory depth for per-directory distribution")
+	rootCmd.AddCommand(ageCmd)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+)
+
+var busFactorCmd = &cobra.Command{
+	Use:   "busfactor",
+	Short: "Report knowledge concentration",
+	Long:  "Busfactor computes the minimal number of authors whose departure would orphan more than the threshold of lines, for the repository and each directory, and flags files dominated by a single author.",
+	Args:  cobra.NoArgs,
+	Run:   busFactorCommand,
+}
+
+func busFactorCommand(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+
+	threshold, e1 := cmd.Flags().GetFloat64("threshold")
+	ownerShare, e2 := cmd.Flags().GetFloat64("owner-share")
+	depth, e3 := cmd.Flags().GetInt("depth")
+	if utils.AnyError(e1, e2, e3) {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+	if threshold <= 0 || threshold >= 100 || ownerShare <= 0 || ownerShare > 100 {
+		fail(utils.ErrorInvalidParameters{Info: "shares must be percentages"}, utils.CodeParametersParsing)
+	}
+	if depth < 0 {
+		fail(utils.ErrorInvalidParameters{Info: "depth must not be negative"}, utils.CodeParametersParsing)
+	}
+
+	st := collectStat(cmd, ps, info)
+
+	output, err := format.RenderTable(format.BusFactorTable(st, threshold/100, ownerShare/100, depth), ps.Format)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	busFactorCmd.Flags().Float64("threshold", 50, "Percentage of lines orphaned by departed authors")
+	busFactorCmd.Flags().Float64("owner-share", 80, "Percentage of lines owned by a single author to flag a file")
+	busFactorCmd.Flags().Int("depth", 1, "Directory depth for per-directory bus factor")
+	rootCmd.AddCommand(busFactorCmd)
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+)
+
+var classifyCmd = &cobra.Command{
+	Use:   "classify",
+	Short: "Report surviving lines per commit class",
+	Long:  "Classify reads subjects of the blamed commits and counts surviving lines of every author per conventional commit type or per custom rule.",
+	Args:  cobra.NoArgs,
+	Run:   classifyCommand,
+}
+
+func classifyCommand(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+
+	specs, err := cmd.Flags().GetStringArray("rule")
+	if err != nil {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+
+	ps.Classify = true
+	ps.ClassRules, err = statistics.ParseClassRules(specs)
+	if err != nil {
+		fail(err, utils.CodeParametersParsing)
+	}
+
+	st := collectStat(cmd, ps, info)
+
+	output, err := format.RenderTable(format.ClassTable(st, ps.ClassRules), ps.Format)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	classifyCmd.Flags().StringArray("rule", nil, "Class rule 'name=regex' matched against commit subjects in order (repeatable), conventional commit types if none")
+	rootCmd.AddCommand(classifyCmd)
+}
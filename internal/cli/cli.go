@@ -5,10 +5,11 @@ import (
 	"github.com/20xygen/git-blame/internal/format"
 	"github.com/20xygen/git-blame/internal/statistics"
 	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/files"
 	"github.com/spf13/cobra"
 	"log/slog"
 	"os"
-	"path/filepath"
+	"strings"
 )
 
 var (
@@ -18,69 +19,221 @@ var (
 		Long:  "Blame is a CLI tool to analyze git blame statistics with different formats and features.",
 		Args:  cobra.NoArgs,
 		Run:   command,
+		PersistentPostRun: func(*cobra.Command, []string) {
+			runExitHooks()
+		},
 	}
+
+	// exitHooks run in reverse order when a command ends or fails, e.g.
+	// removing temporary clones.
+	exitHooks []func()
 )
 
-func command(cmd *cobra.Command, _ []string) {
+func atExit(hook func()) {
+	exitHooks = append(exitHooks, hook)
+}
+
+func runExitHooks() {
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+	exitHooks = nil
+}
+
+// writeOutput prints the report to stdout.
+func writeOutput(output string) {
+	if _, err := fmt.Print(output); err != nil {
+		fail(utils.ErrorOutput{E: err}, utils.CodeOutput)
+	}
+}
+
+// isTerminal tells whether the file is a character device like a terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setup configures error reporting and logging.
+func setup(cmd *cobra.Command) {
+	ef, err := cmd.Flags().GetString("error-format")
+	if err != nil || !utils.Contains([]string{"text", "json"}, ef) {
+		fail(utils.ErrorInvalidParameters{Info: fmt.Sprintf("unexpected error format: %q", ef)}, utils.CodeParametersParsing)
+	}
+	errorFormat = ef
+
+	// the logger goes first to log parameter errors
+	logLevel, e1 := cmd.Flags().GetString("log-level")
+	logFile, e2 := cmd.Flags().GetString("log-file")
+	logFormat, e3 := cmd.Flags().GetString("log-format")
+	if utils.AnyError(e1, e2, e3) {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+	logger, err := utils.SetupLogger(utils.LogOptions{
+		Level:  logLevel,
+		File:   logFile,
+		Format: logFormat,
+	})
+	if err != nil {
+		fail(err, utils.CodeParametersParsing)
+	}
+	slog.SetDefault(logger)
+	loggerReady = true
+}
+
+// prepare parses parameters shared by all commands and loads language info.
+func prepare(cmd *cobra.Command) (*statistics.Params, *files.LangInfo) {
+	setup(cmd)
+
 	ps, err := statistics.GetParams(*cmd)
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		slog.Error(err.Error())
-		os.Exit(utils.CodeParametersParsing)
-		return
+		fail(err, exitCode(err, utils.CodeParametersParsing))
 	}
 
-	logger := utils.SetupLogger()
-	slog.SetDefault(logger)
+	mode, err := cmd.Flags().GetString("progress")
+	if err != nil {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+	if mode == "auto" {
+		mode = "none"
+		if isTerminal(os.Stderr) {
+			mode = "text"
+		}
+	}
+	ps.Progress, err = format.NewProgress(os.Stderr, mode)
+	if err != nil {
+		fail(err, utils.CodeParametersParsing)
+	}
+
+	startProfiling(cmd, ps)
+
+	if ps.Limits != nil {
+		atExit(func() {
+			if len(ps.Limits.Files) == 0 {
+				return
+			}
+			report, err := format.OversizedReport(ps.Limits.Files)
+			if err != nil {
+				slog.Warn("Failed to render oversized files", "error", err)
+				return
+			}
+			_, _ = fmt.Fprint(os.Stderr, report)
+		})
+	}
 
-	ps.Path, err = filepath.Abs(ps.Path)
+	cleanup, err := ps.CloneRemotes()
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		slog.Error(err.Error())
-		os.Exit(utils.CodeAbsolutePath)
-		return
+		fail(err, exitCode(err, utils.CodeGit))
 	}
+	atExit(cleanup)
 
-	info, err := utils.GetLangInfo()
+	err = ps.ResolveRepositories()
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		slog.Error(err.Error())
-		os.Exit(utils.CodeLanguageInfo)
-		return
+		fail(err, exitCode(err, utils.CodeAbsolutePath))
 	}
 
-	st, err := statistics.CollectStat(ps, info)
+	warnings, err := ps.Preflight()
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		slog.Error(err.Error())
-		os.Exit(3)
-		return
+		fail(err, exitCode(err, utils.CodeGit))
+	}
+	for _, warning := range warnings {
+		_, _ = fmt.Fprintln(os.Stderr, "warning:", warning)
 	}
 
-	output, err := format.AutoFormat(st, ps.OrderBy, ps.Format)
+	info, err := utils.GetLangInfo()
 	if err != nil {
-		_, _ = fmt.Fprintln(os.Stderr, err)
-		slog.Error(err.Error())
-		os.Exit(utils.CodeFormat)
+		fail(err, utils.CodeLanguageInfo)
 	}
 
-	fmt.Print(output)
+	// registered last to clear the progress line before other exit output
+	if ps.Progress != nil {
+		atExit(ps.Progress.Finish)
+	}
+
+	return ps, info
+}
+
+func command(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+
+	st := collectStat(cmd, ps, info)
+
+	output, err := format.AutoFormat(st, ps)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
 
 	slog.Info("Done successfully")
 }
 
 func Execute() error {
-	return rootCmd.Execute()
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	if err := rootCmd.Execute(); err != nil {
+		// cobra fails before setup, e.g. on unknown flags
+		errorFormat = argsErrorFormat(os.Args[1:])
+		fail(utils.ErrorInvalidParameters{Info: err.Error()}, utils.CodeParametersParsing)
+	}
+	return nil
+}
+
+// argsErrorFormat finds the error format in raw arguments, which are not
+// parsed when cobra fails.
+func argsErrorFormat(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		value, ok := strings.CutPrefix(arg, "--error-format=")
+		if !ok && arg == "--error-format" && i+1 < len(args) {
+			value, ok = args[i+1], true
+		}
+		if ok && value == "json" {
+			return value
+		}
+	}
+	return "text"
 }
 
 func init() {
-	rootCmd.Flags().StringP("repository", "r", ".", "Git repository path")
-	rootCmd.Flags().StringP("revision", "R", "HEAD", "Git revision")
-	rootCmd.Flags().StringSliceP("order-by", "o", []string{"lines", "commits", "files"}, "Sort key as comma-separated list of 'lines', 'commits', 'names' or 'files'")
-	rootCmd.Flags().BoolP("use-committer", "C", false, "Use committer instead of author")
-	rootCmd.Flags().StringSliceP("extensions", "e", nil, "File extensions filter (comma-separated)")
-	rootCmd.Flags().StringSliceP("languages", "l", nil, "Languages filter (comma-separated)")
-	rootCmd.Flags().StringSliceP("exclude", "x", nil, "Exclude glob patterns")
-	rootCmd.Flags().StringSliceP("restrict-to", "t", nil, "Restrict-to glob patterns")
-	rootCmd.Flags().StringP("format", "f", "tabular", "Output format (one of 'pretty', 'tabular', 'json', 'json-lines', 'csv')'")
+	flags := rootCmd.PersistentFlags()
+	flags.StringArrayP("repository", "r", []string{"."}, "Git repository, bundle or file:// URL (repeatable)")
+	flags.StringP("manifest", "m", "", "YAML manifest listing repositories with their paths and revisions")
+	flags.StringP("revision", "R", "HEAD", "Git revision")
+	flags.StringSliceP("order-by", "o", []string{"lines", "commits", "files"}, "Sort keys with optional direction, e.g. 'lines:asc,names:desc'")
+	flags.BoolP("use-committer", "C", false, "Use committer instead of author")
+	flags.StringSliceP("extensions", "e", nil, "File extensions filter (comma-separated)")
+	flags.StringSliceP("languages", "l", nil, "Languages filter (comma-separated)")
+	flags.StringSliceP("exclude", "x", nil, "Exclude glob patterns")
+	flags.StringSliceP("restrict-to", "t", nil, "Restrict-to glob patterns")
+	flags.StringP("format", "f", "tabular", "Output format (one of 'pretty', 'tabular', 'json', 'json-lines', 'csv')'")
+	flags.Bool("recurse-submodules", false, "Descend into checked-out submodules at their pinned commits")
+	flags.Bool("show-repositories", false, "Show lines per repository for each author")
+	flags.Bool("worktree", false, "Blame the working copy including staged and unstaged changes")
+	flags.String("range", "", "Blame the revision range A..B, lines older than A go to 'Boundary'")
+	flags.String("max-file-size", "", "Treat files larger than the size, e.g. '512K' or '10M', as oversized")
+	flags.Int("max-lines", 0, "Treat files with more lines than the number as oversized")
+	flags.String("oversized", "skip", "What to do with oversized files (one of 'skip', 'last-commit'), 'last-commit' attributes all lines to the last commit changing the file")
+	flags.Bool("allow-shallow", false, "Allow shallow clones where old lines go to the oldest fetched commits")
+	flags.String("baseline", "", "Snapshot of previous results, only files changed since its revisions are blamed again")
+	flags.String("snapshot", "", "Write a snapshot of results to the file, the baseline is replaced if not given")
+	flags.String("teams", "", "YAML file mapping identities to teams")
+	flags.Bool("team-members", false, "Expand team members under their teams")
+	flags.String("coauthors", "ignore", "Credit Co-authored-by trailers (one of 'ignore', 'split', 'full')")
+	flags.String("count", "all", "Kind of lines to count (one of 'all', 'code', 'comment', 'blank')")
+	flags.Bool("line-kinds", false, "Show numbers of code, comment and blank lines")
+	flags.String("error-format", "text", "Format of errors printed to stderr (one of 'text', 'json')")
+	flags.String("log-level", "info", "Log level (one of 'debug', 'info', 'warn', 'error')")
+	flags.String("log-file", utils.DefaultLogFile, "Log file, '-' for stderr")
+	flags.String("log-format", "json", "Log format (one of 'text', 'json')")
+	flags.Int("profile-report", 0, "Print the given number of the slowest files with totals per extension and directory to stderr")
+	flags.String("cpu-profile", "", "Write a pprof CPU profile of the run to the file")
+	flags.String("heap-profile", "", "Write a pprof heap profile at the end of the run to the file")
+	flags.String("progress", "auto", "Progress on stderr (one of 'auto', 'text', 'json', 'none'), 'auto' shows text on terminals")
+	flags.String("score", "", "Score expression like '0.6*lines_share + 0.4*recency' or preset ('balanced', 'activity', 'lines')")
+	rootCmd.MarkFlagsMutuallyExclusive("manifest", "repository")
+	rootCmd.MarkFlagsMutuallyExclusive("worktree", "revision")
+	rootCmd.MarkFlagsMutuallyExclusive("range", "revision")
+	rootCmd.MarkFlagsMutuallyExclusive("range", "worktree")
 }
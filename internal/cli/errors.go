@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/commands"
+	"log/slog"
+	"os"
+)
+
+// errorFormat is the format of errors printed to stderr, 'text' or 'json'.
+var errorFormat = "text"
+
+// loggerReady tells errors may be logged, the default logger writes to stderr.
+var loggerReady bool
+
+// errorClasses name exit codes in machine-readable errors.
+var errorClasses = map[int]string{
+	utils.CodeParametersParsing: "invalid-parameters",
+	utils.CodeAbsolutePath:      "path",
+	utils.CodeLanguageInfo:      "language-info",
+	utils.CodeFormat:            "format",
+	utils.CodeNotRepository:     "not-repository",
+	utils.CodeBadRevision:       "bad-revision",
+	utils.CodeGit:               "git",
+	utils.CodeParse:             "parse",
+	utils.CodeOutput:            "output",
+	utils.CodeShallow:           "shallow-repository",
+}
+
+// exitCode returns the exit code of the error class, or the fallback for
+// errors of no known class.
+func exitCode(err error, fallback int) int {
+	var params utils.ErrorInvalidParameters
+	var lang utils.ErrorUndefinedLanguage
+	var pattern utils.ErrorInvalidPattern
+	var config utils.ErrorConfigFile
+	var output utils.ErrorOutput
+	var execErr commands.ErrorCommandExecution
+
+	switch {
+	case errors.As(err, &params), errors.As(err, &lang), errors.As(err, &pattern), errors.As(err, &config):
+		return utils.CodeParametersParsing
+	case errors.Is(err, commands.ErrNotRepository):
+		return utils.CodeNotRepository
+	case errors.Is(err, commands.ErrBadRevision):
+		return utils.CodeBadRevision
+	case errors.Is(err, commands.ErrShallow):
+		return utils.CodeShallow
+	case errors.Is(err, commands.ErrInvalidOutput):
+		return utils.CodeParse
+	case errors.As(err, &output):
+		return utils.CodeOutput
+	case errors.As(err, &execErr):
+		return utils.CodeGit
+	}
+	return fallback
+}
+
+// fail reports the error and terminates the process with the code. Exit hooks
+// run first, so the error is the last thing printed.
+func fail(err error, code int) {
+	runExitHooks()
+	if errorFormat == "json" {
+		data, _ := json.Marshal(struct {
+			Error string `json:"error"`
+			Class string `json:"class"`
+			Code  int    `json:"code"`
+		}{err.Error(), errorClasses[code], code})
+		_, _ = fmt.Fprintln(os.Stderr, string(data))
+	} else {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+	}
+	if loggerReady {
+		slog.Error(err.Error(), "code", code)
+	}
+	os.Exit(code)
+}
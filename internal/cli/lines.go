@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bufio"
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+)
+
+var linesCmd = &cobra.Command{
+	Use:   "lines",
+	Short: "Export per-line blame data",
+	Long:  "Lines prints path, line number, commit, name, email and timestamp of every blamed line as 'json-lines' (default) or 'csv'.",
+	Args:  cobra.NoArgs,
+	Run:   linesCommand,
+}
+
+func linesCommand(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+	noSnapshots(cmd)
+	if !cmd.Flags().Changed("format") {
+		ps.Format = "json-lines"
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	lw, err := format.NewLineWriter(out, ps)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	err = statistics.CollectLines(ps, info, func(rec *statistics.LineRecord) error {
+		if err := lw.Write(rec); err != nil {
+			return utils.ErrorOutput{E: err}
+		}
+		return nil
+	})
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeGit))
+	}
+
+	if err = lw.Flush(); err != nil {
+		fail(utils.ErrorOutput{E: err}, utils.CodeOutput)
+	}
+	if err = out.Flush(); err != nil {
+		fail(utils.ErrorOutput{E: err}, utils.CodeOutput)
+	}
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	rootCmd.AddCommand(linesCmd)
+}
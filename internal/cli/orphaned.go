@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"time"
+)
+
+var orphanedCmd = &cobra.Command{
+	Use:   "orphaned",
+	Short: "Report code owned by inactive contributors",
+	Long:  "Orphaned reports lines, files and directories whose owners are all inactive. Active identities are read from a file or taken from commits within the last days before the revision.",
+	Args:  cobra.NoArgs,
+	Run:   orphanedCommand,
+}
+
+func orphanedCommand(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+
+	activePath, e1 := cmd.Flags().GetString("active")
+	activeDays, e2 := cmd.Flags().GetInt("active-days")
+	depth, e3 := cmd.Flags().GetInt("depth")
+	if utils.AnyError(e1, e2, e3) {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+	if activeDays <= 0 {
+		fail(utils.ErrorInvalidParameters{Info: "active days must be positive"}, utils.CodeParametersParsing)
+	}
+	if depth < 0 {
+		fail(utils.ErrorInvalidParameters{Info: "depth must not be negative"}, utils.CodeParametersParsing)
+	}
+
+	st := collectStat(cmd, ps, info)
+
+	var active map[string]struct{}
+	var err error
+	if activePath != "" {
+		active, err = statistics.ReadActive(activePath)
+	} else {
+		active, err = statistics.ActiveAuthors(st, ps, time.Duration(activeDays)*24*time.Hour)
+	}
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeGit))
+	}
+
+	output, err := format.RenderTable(format.OrphanedTable(st, active, depth), ps.Format)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	orphanedCmd.Flags().String("active", "", "File with active identities, one name per line")
+	orphanedCmd.Flags().Int("active-days", 180, "Identities with commits within the days before the revision are active")
+	orphanedCmd.Flags().Int("depth", 1, "Directory depth for per-directory report")
+	orphanedCmd.MarkFlagsMutuallyExclusive("active", "active-days")
+	rootCmd.AddCommand(orphanedCmd)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// startProfiling enables the profile report and pprof profiles requested by
+// flags. Reports and profiles are written when the command ends.
+func startProfiling(cmd *cobra.Command, ps *statistics.Params) {
+	slowest, e1 := cmd.Flags().GetInt("profile-report")
+	cpuPath, e2 := cmd.Flags().GetString("cpu-profile")
+	heapPath, e3 := cmd.Flags().GetString("heap-profile")
+	if utils.AnyError(e1, e2, e3) {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+	if slowest < 0 {
+		fail(utils.ErrorInvalidParameters{Info: "profile report size must not be negative"}, utils.CodeParametersParsing)
+	}
+
+	if slowest > 0 {
+		ps.Profile = &statistics.Profile{}
+		atExit(func() {
+			report, err := format.ProfileReport(ps.Profile, slowest)
+			if err != nil {
+				slog.Warn("Failed to render profile report", "error", err)
+				return
+			}
+			_, _ = fmt.Fprint(os.Stderr, report)
+		})
+	}
+
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			fail(utils.ErrorOutput{E: err}, utils.CodeOutput)
+		}
+		if err = pprof.StartCPUProfile(f); err != nil {
+			fail(utils.ErrorOutput{E: err}, utils.CodeOutput)
+		}
+		atExit(func() {
+			pprof.StopCPUProfile()
+			_ = f.Close()
+		})
+	}
+
+	if heapPath != "" {
+		atExit(func() {
+			f, err := os.Create(heapPath)
+			if err != nil {
+				slog.Warn("Failed to write heap profile", "error", err)
+				return
+			}
+			defer func() { _ = f.Close() }()
+			runtime.GC()
+			if err = pprof.WriteHeapProfile(f); err != nil {
+				slog.Warn("Failed to write heap profile", "error", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+)
+
+var renderCmd = &cobra.Command{
+	Use:   "render snapshot.json",
+	Short: "Render a report from a snapshot",
+	Long:  "Render loads statistics saved with --snapshot and prints them in any format, with any sorting, score and teams, without running git.",
+	Args:  cobra.ExactArgs(1),
+	Run:   renderCommand,
+}
+
+func renderCommand(cmd *cobra.Command, args []string) {
+	setup(cmd)
+	noSnapshots(cmd)
+	noCollection(cmd)
+
+	ps, err := statistics.GetParams(*cmd)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeParametersParsing))
+	}
+
+	s, err := statistics.ReadSnapshot(args[0])
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeParametersParsing))
+	}
+	if err = s.Restore(ps); err != nil {
+		fail(err, utils.CodeParametersParsing)
+	}
+
+	output, err := format.AutoFormat(s.Stat(), ps)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	rootCmd.AddCommand(renderCmd)
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/files"
+	"github.com/spf13/cobra"
+	"io/fs"
+	"os"
+)
+
+// collectStat collects statistics starting from the baseline snapshot, if
+// given, and saves the snapshot of results if asked. The baseline is replaced
+// by the new snapshot unless another file is given.
+func collectStat(cmd *cobra.Command, ps *statistics.Params, info *files.LangInfo) *statistics.Stat {
+	baseline, e1 := cmd.Flags().GetString("baseline")
+	snapshot, e2 := cmd.Flags().GetString("snapshot")
+	if utils.AnyError(e1, e2) {
+		fail(utils.ErrorInvalidParameters{Info: "unexpected error"}, utils.CodeParametersParsing)
+	}
+
+	if baseline != "" {
+		if snapshot == "" {
+			snapshot = baseline
+		}
+
+		s, err := statistics.ReadSnapshot(baseline)
+		var version statistics.ErrorSnapshotVersion
+		if errors.Is(err, fs.ErrNotExist) {
+			_, _ = fmt.Fprintln(os.Stderr, "warning: baseline", baseline, "not found, blaming all files")
+		} else if errors.As(err, &version) {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: baseline %s has unsupported snapshot version %d, blaming all files\n", baseline, version.Version)
+		} else if err != nil {
+			fail(err, exitCode(err, utils.CodeParametersParsing))
+		} else {
+			ps.Baseline, err = statistics.NewBaseline(s, ps)
+			if err != nil {
+				fail(err, exitCode(err, utils.CodeGit))
+			}
+		}
+	}
+
+	st, err := statistics.CollectStat(ps, info)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeGit))
+	}
+
+	if snapshot != "" {
+		if err = statistics.NewSnapshot(st, ps).Write(snapshot); err != nil {
+			fail(err, utils.CodeOutput)
+		}
+	}
+	return st
+}
+
+// collectionFlags are parameters of collecting statistics, which a snapshot
+// already has.
+var collectionFlags = []string{
+	"repository", "manifest", "revision", "range", "worktree", "recurse-submodules", "allow-shallow",
+	"use-committer", "extensions", "languages", "exclude", "restrict-to", "coauthors", "count",
+	"max-file-size", "max-lines", "oversized",
+}
+
+// noCollection rejects parameters of collecting statistics for commands
+// rendering snapshots.
+func noCollection(cmd *cobra.Command) {
+	for _, name := range collectionFlags {
+		if cmd.Flags().Changed(name) {
+			fail(utils.ErrorInvalidParameters{Info: fmt.Sprintf("--%s is taken from the snapshot by %s", name, cmd.Name())}, utils.CodeParametersParsing)
+		}
+	}
+}
+
+// noSnapshots rejects snapshots for commands not collecting aggregate statistics.
+func noSnapshots(cmd *cobra.Command) {
+	if cmd.Flags().Changed("baseline") || cmd.Flags().Changed("snapshot") {
+		fail(utils.ErrorInvalidParameters{Info: "--baseline and --snapshot are not supported by " + cmd.Name()}, utils.CodeParametersParsing)
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"github.com/20xygen/git-blame/internal/format"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/spf13/cobra"
+	"log/slog"
+)
+
+var symbolsCmd = &cobra.Command{
+	Use:   "symbols",
+	Short: "Report ownership of Go declarations",
+	Long:  "Symbols parses Go files at the revision and reports the primary author and their share of lines for every top-level function, method and type.",
+	Args:  cobra.NoArgs,
+	Run:   symbolsCommand,
+}
+
+func symbolsCommand(cmd *cobra.Command, _ []string) {
+	ps, info := prepare(cmd)
+	noSnapshots(cmd)
+
+	stats, err := statistics.CollectSymbols(ps, info)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeGit))
+	}
+
+	output, err := format.RenderTable(format.SymbolTable(stats), ps.Format)
+	if err != nil {
+		fail(err, exitCode(err, utils.CodeFormat))
+	}
+
+	writeOutput(output)
+
+	slog.Info("Done successfully")
+}
+
+func init() {
+	rootCmd.AddCommand(symbolsCmd)
+}
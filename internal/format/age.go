@@ -0,0 +1,60 @@
+package format
+
+import (
+	"github.com/20xygen/git-blame/internal/statistics"
+	"math"
+	"sort"
+)
+
+func ageRow(scope, name string, ages statistics.Ages) []any {
+	row := []any{scope, name, ages.Lines}
+	for _, n := range ages.Buckets {
+		row = append(row, n)
+	}
+	return append(row, math.Round(ages.Median.Hours()/24*100)/100)
+}
+
+// AgeTable reports line age distributions per author, per directory cut at the depth
+// and for the whole repository.
+func AgeTable(st *statistics.Stat, buckets []statistics.AgeBucket, depth int) *Table {
+	t := &Table{
+		Header: []string{"Scope", "Name", "Lines"},
+	}
+	for _, b := range buckets {
+		t.Header = append(t.Header, b.Label)
+	}
+	t.Header = append(t.Header, "Median Days")
+
+	type named struct {
+		name string
+		ages statistics.Ages
+	}
+
+	users := make([]named, 0, len(st.Users))
+	for name, usr := range st.Users {
+		users = append(users, named{name, statistics.ComputeAges(usr.Times, st.Time, buckets)})
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].ages.Lines != users[j].ages.Lines {
+			return users[i].ages.Lines > users[j].ages.Lines
+		}
+		return users[i].name < users[j].name
+	})
+	for _, u := range users {
+		t.Append(ageRow("author", u.name, u.ages)...)
+	}
+
+	dirTimes := st.DirTimes(depth)
+	dirs := make([]string, 0, len(dirTimes))
+	for dir := range dirTimes {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		t.Append(ageRow("directory", dir, statistics.ComputeAges(dirTimes[dir], st.Time, buckets))...)
+	}
+
+	t.Append(ageRow("total", "", statistics.ComputeAges(st.TotalTimes(), st.Time, buckets))...)
+
+	return t
+}
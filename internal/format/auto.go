@@ -6,9 +6,9 @@ import (
 	"github.com/20xygen/git-blame/internal/utils"
 )
 
-func AutoFormat(st *statistics.Stat, sortKey []string, outFormat string) (string, error) {
-	var tool func(*statistics.Stat, []string) (string, error)
-	switch outFormat {
+func AutoFormat(st *statistics.Stat, ps *statistics.Params) (string, error) {
+	var tool func(*statistics.Stat, *statistics.Params) (string, error)
+	switch ps.Format {
 	case "tabular":
 		tool = statTabular
 	case "json":
@@ -21,9 +21,9 @@ func AutoFormat(st *statistics.Stat, sortKey []string, outFormat string) (string
 		tool = statPretty
 	default:
 		return "", utils.ErrorInvalidParameters{
-			Info: fmt.Sprintf("unexpected format: %q", outFormat),
+			Info: fmt.Sprintf("unexpected format: %q", ps.Format),
 		}
 	}
 
-	return tool(st, sortKey)
+	return tool(st, ps)
 }
@@ -0,0 +1,45 @@
+package format
+
+import (
+	"github.com/20xygen/git-blame/internal/statistics"
+	"math"
+	"sort"
+)
+
+func ownershipRow(scope, name string, own statistics.Ownership) []any {
+	return []any{scope, name, own.Lines, own.BusFactor, own.Top, math.Round(own.TopShare*10000) / 100}
+}
+
+// BusFactorTable reports bus factors of the repository and directories cut at the depth,
+// followed by files where a single author owns more than ownerShare of lines.
+func BusFactorTable(st *statistics.Stat, threshold, ownerShare float64, depth int) *Table {
+	t := &Table{
+		Header: []string{"Scope", "Name", "Lines", "Bus Factor", "Top Author", "Top Share"},
+	}
+
+	t.Append(ownershipRow("total", "", statistics.ComputeOwnership(st.TotalUsers(), threshold))...)
+
+	dirUsers := st.DirUsers(depth)
+	dirs := make([]string, 0, len(dirUsers))
+	for dir := range dirUsers {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		t.Append(ownershipRow("directory", dir, statistics.ComputeOwnership(dirUsers[dir], threshold))...)
+	}
+
+	paths := make([]string, 0, len(st.Files))
+	for p := range st.Files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		own := statistics.ComputeOwnership(st.Files[p].Users, threshold)
+		if own.Lines > 0 && own.TopShare > ownerShare {
+			t.Append(ownershipRow("file", p, own)...)
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,74 @@
+package format
+
+import (
+	"github.com/20xygen/git-blame/internal/statistics"
+	"sort"
+)
+
+// ClassTable reports surviving lines of every author per commit class. Classes
+// go in the rules order, or by lines for conventional commit types, "other" last.
+func ClassTable(st *statistics.Stat, rules []statistics.ClassRule) *Table {
+	totals := make(map[string]int)
+	for _, usr := range st.Users {
+		for class, n := range usr.Classes {
+			totals[class] += n
+		}
+	}
+
+	var classes []string
+	if len(rules) > 0 {
+		for _, rule := range rules {
+			if !containsClass(classes, rule.Name) {
+				classes = append(classes, rule.Name)
+			}
+		}
+	} else {
+		for class := range totals {
+			if class != statistics.ClassOther {
+				classes = append(classes, class)
+			}
+		}
+		sort.Slice(classes, func(i, j int) bool {
+			if totals[classes[i]] != totals[classes[j]] {
+				return totals[classes[i]] > totals[classes[j]]
+			}
+			return classes[i] < classes[j]
+		})
+	}
+	classes = append(classes, statistics.ClassOther)
+
+	t := &Table{
+		Header: append([]string{"Name", "Lines"}, classes...),
+	}
+
+	names := make([]string, 0, len(st.Users))
+	for name := range st.Users {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if st.Users[names[i]].Lines != st.Users[names[j]].Lines {
+			return st.Users[names[i]].Lines > st.Users[names[j]].Lines
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		usr := st.Users[name]
+		row := []any{name, usr.Lines}
+		for _, class := range classes {
+			row = append(row, usr.Classes[class])
+		}
+		t.Append(row...)
+	}
+
+	return t
+}
+
+func containsClass(classes []string, class string) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/20xygen/git-blame/internal/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 )
@@ -15,126 +16,228 @@ import (
 type statUnit struct {
 	Name string `json:"name"`
 	statistics.StatVals
-}
+	*statistics.LineKinds
+	Score *float64 `json:"score,omitempty"`
 
-func validateSortKey(sortKey []string) error {
-	valid := []string{"lines", "commits", "files", "names"}
-	for _, key := range sortKey {
-		if !utils.Contains(valid, key) {
-			return utils.ErrorInvalidParameters{
-				Info: fmt.Sprintf("unexpected sort key: %s", key),
-			}
-		}
-	}
-	return nil
+	LinesPercent   *float64 `json:"lines_percent,omitempty"`
+	CommitsPercent *float64 `json:"commits_percent,omitempty"`
+	FilesPercent   *float64 `json:"files_percent,omitempty"`
+	Age            *float64 `json:"age,omitempty"` // median age of lines in days
+
+	Repositories map[string]int `json:"repositories,omitempty"`
+	Members      []*statUnit    `json:"members,omitempty"`
 }
 
-func sorted(st *statistics.Stat, sortKey []string) ([]*statUnit, error) {
+func newUnits(st *statistics.Stat, ps *statistics.Params, uv *unitValues) []*statUnit {
 	units := make([]*statUnit, 0, len(st.Users))
 	for name, user := range st.Users {
-		units = append(units, &statUnit{
+		unit := &statUnit{
 			Name:     name,
 			StatVals: user.Total(),
-		})
+		}
+		if ps.ShowRepositories {
+			unit.Repositories = user.Repos
+		}
+		if ps.LineKinds {
+			kinds := user.Kinds
+			unit.LineKinds = &kinds
+		}
+		uv.fill(unit, user)
+		units = append(units, unit)
 	}
+	return units
+}
 
-	if err := validateSortKey(sortKey); err != nil {
+// sorted returns users, or teams with their sorted members if teams are given.
+func sorted(st *statistics.Stat, ps *statistics.Params) ([]*statUnit, error) {
+	keys, err := parseSortKeys(ps.OrderBy, ps)
+	if err != nil {
 		return nil, err
 	}
+	uv := newUnitValues(st, ps, keys)
+
+	if ps.Teams == nil {
+		units := newUnits(st, ps, uv)
+		sortUnits(units, keys)
+		return units, nil
+	}
 
-	fullSortKey := append([]string{}, sortKey...)
-	fullSortKey = append(fullSortKey, "lines")
-	fullSortKey = append(fullSortKey, "commits")
-	fullSortKey = append(fullSortKey, "files")
-
-	sort.Slice(units, func(i, j int) bool {
-		for _, key := range fullSortKey {
-			switch strings.ToLower(key) {
-			case "lines":
-				if units[i].Lines != units[j].Lines {
-					return units[i].Lines > units[j].Lines
-				}
-			case "commits":
-				if units[i].Commits != units[j].Commits {
-					return units[i].Commits > units[j].Commits
-				}
-			case "files":
-				if units[i].Files != units[j].Files {
-					return units[i].Files > units[j].Files
-				}
-			case "names":
-				if units[i].Name != units[j].Name {
-					return units[i].Name < units[j].Name
-				}
-			}
+	teams, members := st.GroupByTeams(ps.Teams)
+	units := newUnits(teams, ps, uv)
+	sortUnits(units, keys)
+	if ps.TeamMembers {
+		for _, unit := range units {
+			unit.Members = newUnits(members[unit.Name], ps, uv)
+			sortUnits(unit.Members, keys)
 		}
-		return units[i].Name < units[j].Name
+	}
+	return units, nil
+}
+
+// column is a column of tabular outputs.
+type column struct {
+	Header string
+	Value  func(*statUnit) any
+}
+
+var (
+	nameColumn    = column{"Name", func(u *statUnit) any { return u.Name }}
+	linesColumn   = column{"Lines", func(u *statUnit) any { return u.Lines }}
+	commitsColumn = column{"Commits", func(u *statUnit) any { return u.Commits }}
+	filesColumn   = column{"Files", func(u *statUnit) any { return u.Files }}
+)
+
+// extraColumns returns the optional columns enabled by parameters.
+func extraColumns(ps *statistics.Params) []column {
+	var columns []column
+	if ps.LineKinds {
+		columns = append(columns,
+			column{"Code", func(u *statUnit) any { return u.Code }},
+			column{"Comment", func(u *statUnit) any { return u.Comment }},
+			column{"Blank", func(u *statUnit) any { return u.Blank }},
+		)
+	}
+	if ps.Score != nil {
+		columns = append(columns, column{"Score", func(u *statUnit) any {
+			return strconv.FormatFloat(deref(u.Score), 'f', 4, 64)
+		}})
+	}
+	// keys are validated by sorted
+	keys, _ := parseSortKeys(ps.OrderBy, ps)
+	columns = append(columns, computedColumns(keys)...)
+	if ps.ShowRepositories {
+		columns = append(columns, column{"Repositories", func(u *statUnit) any {
+			return repositoriesString(u.Repositories)
+		}})
+	}
+	return columns
+}
+
+func repositoriesString(repos map[string]int) string {
+	names := make([]string, 0, len(repos))
+	for name := range repos {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if repos[names[i]] != repos[names[j]] {
+			return repos[names[i]] > repos[names[j]]
+		}
+		return names[i] < names[j]
 	})
 
-	return units, nil
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s (%d)", name, repos[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// grid lays units out in rows. Expanded team members follow their team row
+// and a leading Team column tells which team a row belongs to.
+func grid(units []*statUnit, ps *statistics.Params, columns []column) ([]string, [][]any) {
+	columns = append(columns, extraColumns(ps)...)
+	grouped := ps.Teams != nil && ps.TeamMembers
+
+	var header []string
+	if grouped {
+		header = append(header, "Team")
+	}
+	for _, col := range columns {
+		header = append(header, col.Header)
+	}
+
+	values := func(u *statUnit) []any {
+		row := make([]any, 0, len(columns))
+		for _, col := range columns {
+			row = append(row, col.Value(u))
+		}
+		return row
+	}
+
+	rows := make([][]any, 0, len(units))
+	for _, unit := range units {
+		if !grouped {
+			rows = append(rows, values(unit))
+			continue
+		}
+
+		row := values(unit)
+		row[0] = ""
+		rows = append(rows, append([]any{unit.Name}, row...))
+		for _, member := range unit.Members {
+			rows = append(rows, append([]any{unit.Name}, values(member)...))
+		}
+	}
+
+	return header, rows
 }
 
-func statTabular(st *statistics.Stat, sortKey []string) (string, error) {
-	units, err := sorted(st, sortKey)
+func statTabular(st *statistics.Stat, ps *statistics.Params) (string, error) {
+	units, err := sorted(st, ps)
 	if err != nil {
 		return "", err
 	}
 
+	header, rows := grid(units, ps, []column{nameColumn, linesColumn, commitsColumn, filesColumn})
+
 	var builder strings.Builder
 	writer := tabwriter.NewWriter(&builder, 0, 0, 1, ' ', 0)
 
-	_, _ = fmt.Fprintln(writer, "Name\tLines\tCommits\tFiles")
-	for _, unit := range units {
-		_, _ = fmt.Fprintf(writer, "%s\t%d\t%d\t%d\n", unit.Name, unit.Lines, unit.Commits, unit.Files)
+	_, _ = fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		_, _ = fmt.Fprintln(writer, strings.Join(rowStrings(row), "\t"))
 	}
 
 	_ = writer.Flush()
 	return builder.String(), nil
 }
 
-func statPretty(st *statistics.Stat, sortKey []string) (string, error) {
-	units, err := sorted(st, sortKey)
+func statPretty(st *statistics.Stat, ps *statistics.Params) (string, error) {
+	units, err := sorted(st, ps)
 	if err != nil {
 		return "", err
 	}
 
+	header, rows := grid(units, ps, []column{nameColumn, commitsColumn, filesColumn, linesColumn})
+
 	var builder strings.Builder
 
 	t := table.NewWriter()
 	t.SetOutputMirror(&builder)
-	t.AppendHeader(table.Row{"Name", "Commits", "Files", "Lines"})
-	rows := make([]table.Row, 0, len(st.Users))
-	for _, unit := range units {
-		rows = append(rows, table.Row{unit.Name, unit.Commits, unit.Files, unit.Lines})
+	headerRow := make(table.Row, 0, len(header))
+	for _, h := range header {
+		headerRow = append(headerRow, h)
+	}
+	t.AppendHeader(headerRow)
+	tableRows := make([]table.Row, 0, len(rows))
+	for _, row := range rows {
+		tableRows = append(tableRows, row)
 	}
-	t.AppendRows(rows)
+	t.AppendRows(tableRows)
 	t.AppendSeparator()
 	t.Render()
 
 	return builder.String(), nil
 }
 
-func statCSV(st *statistics.Stat, sortKey []string) (string, error) {
-	units, err := sorted(st, sortKey)
+func statCSV(st *statistics.Stat, ps *statistics.Params) (string, error) {
+	units, err := sorted(st, ps)
 	if err != nil {
 		return "", err
 	}
 
+	header, rows := grid(units, ps, []column{nameColumn, linesColumn, commitsColumn, filesColumn})
+
 	var builder strings.Builder
 	writer := csv.NewWriter(&builder)
 
-	err = writer.Write([]string{"Name", "Lines", "Commits", "Files"})
+	err = writer.Write(header)
 	if err != nil {
 		return "", err
 	}
 
-	for _, u := range units {
-		err = writer.Write([]string{
-			u.Name,
-			fmt.Sprintf("%d", u.Lines),
-			fmt.Sprintf("%d", u.Commits),
-			fmt.Sprintf("%d", u.Files),
-		})
+	for _, row := range rows {
+		err = writer.Write(rowStrings(row))
 		if err != nil {
 			return "", err
 		}
@@ -145,8 +248,8 @@ func statCSV(st *statistics.Stat, sortKey []string) (string, error) {
 	return builder.String(), nil
 }
 
-func statJSON(st *statistics.Stat, sortKey []string) (string, error) {
-	units, err := sorted(st, sortKey)
+func statJSON(st *statistics.Stat, ps *statistics.Params) (string, error) {
+	units, err := sorted(st, ps)
 	if err != nil {
 		return "", err
 	}
@@ -158,8 +261,8 @@ func statJSON(st *statistics.Stat, sortKey []string) (string, error) {
 	return string(jsonData), nil
 }
 
-func statJSONLines(st *statistics.Stat, sortKey []string) (string, error) {
-	units, err := sorted(st, sortKey)
+func statJSONLines(st *statistics.Stat, ps *statistics.Params) (string, error) {
+	units, err := sorted(st, ps)
 	if err != nil {
 		return "", err
 	}
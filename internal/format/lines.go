@@ -0,0 +1,80 @@
+package format
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"io"
+)
+
+// LineWriter writes line records one by one so the whole output is never held in memory.
+type LineWriter interface {
+	Write(rec *statistics.LineRecord) error
+	Flush() error
+}
+
+func NewLineWriter(w io.Writer, ps *statistics.Params) (LineWriter, error) {
+	switch ps.Format {
+	case "json-lines":
+		return &jsonLineWriter{
+			enc: json.NewEncoder(w),
+		}, nil
+	case "csv":
+		lw := &csvLineWriter{
+			writer:     csv.NewWriter(w),
+			repository: ps.ShowRepositories,
+		}
+		header := []string{"path", "line", "commit", "name", "email", "timestamp"}
+		if lw.repository {
+			header = append([]string{"repository"}, header...)
+		}
+		if err := lw.writer.Write(header); err != nil {
+			return nil, err
+		}
+		return lw, nil
+	default:
+		return nil, utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("unexpected lines format: %q", ps.Format),
+		}
+	}
+}
+
+type jsonLineWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonLineWriter) Write(rec *statistics.LineRecord) error {
+	if err := w.enc.Encode(rec); err != nil {
+		return utils.ErrorJSONSerialization{}
+	}
+	return nil
+}
+
+func (w *jsonLineWriter) Flush() error { return nil }
+
+type csvLineWriter struct {
+	writer     *csv.Writer
+	repository bool
+}
+
+func (w *csvLineWriter) Write(rec *statistics.LineRecord) error {
+	row := []string{
+		rec.Path,
+		fmt.Sprintf("%d", rec.Line),
+		rec.Commit,
+		rec.Name,
+		rec.Email,
+		fmt.Sprintf("%d", rec.Timestamp),
+	}
+	if w.repository {
+		row = append([]string{rec.Repository}, row...)
+	}
+	return w.writer.Write(row)
+}
+
+func (w *csvLineWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
@@ -0,0 +1,199 @@
+package format
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"math"
+	"sort"
+	"strings"
+)
+
+// sortKey is a parsed order-by key like "lines:asc".
+type sortKey struct {
+	Name string
+	Desc bool
+}
+
+// keyDef describes a sortable column. Numbers go in descending order and
+// names in ascending order unless the direction is given.
+type keyDef struct {
+	value    func(u *statUnit) float64 // nil for names
+	computed *column                   // shown only when sorting by the key
+	requires string                    // flag the column depends on
+	enabled  func(ps *statistics.Params) bool
+}
+
+func deref(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func valueColumn(header string, v func(u *statUnit) *float64) *column {
+	return &column{header, func(u *statUnit) any { return deref(v(u)) }}
+}
+
+var (
+	hasKinds = func(ps *statistics.Params) bool { return ps.LineKinds }
+	hasScore = func(ps *statistics.Params) bool { return ps.Score != nil }
+
+	sortKeys = map[string]keyDef{
+		"names":   {},
+		"lines":   {value: func(u *statUnit) float64 { return float64(u.Lines) }},
+		"commits": {value: func(u *statUnit) float64 { return float64(u.Commits) }},
+		"files":   {value: func(u *statUnit) float64 { return float64(u.Files) }},
+		"score":   {value: func(u *statUnit) float64 { return deref(u.Score) }, requires: "--score", enabled: hasScore},
+		"code":    {value: func(u *statUnit) float64 { return float64(u.Code) }, requires: "--line-kinds", enabled: hasKinds},
+		"comment": {value: func(u *statUnit) float64 { return float64(u.Comment) }, requires: "--line-kinds", enabled: hasKinds},
+		"blank":   {value: func(u *statUnit) float64 { return float64(u.Blank) }, requires: "--line-kinds", enabled: hasKinds},
+		"lines_percent": {
+			value:    func(u *statUnit) float64 { return deref(u.LinesPercent) },
+			computed: valueColumn("Lines %", func(u *statUnit) *float64 { return u.LinesPercent }),
+		},
+		"commits_percent": {
+			value:    func(u *statUnit) float64 { return deref(u.CommitsPercent) },
+			computed: valueColumn("Commits %", func(u *statUnit) *float64 { return u.CommitsPercent }),
+		},
+		"files_percent": {
+			value:    func(u *statUnit) float64 { return deref(u.FilesPercent) },
+			computed: valueColumn("Files %", func(u *statUnit) *float64 { return u.FilesPercent }),
+		},
+		"age": {
+			value:    func(u *statUnit) float64 { return deref(u.Age) },
+			computed: valueColumn("Age Days", func(u *statUnit) *float64 { return u.Age }),
+		},
+	}
+)
+
+func sortKeyNames() []string {
+	names := make([]string, 0, len(sortKeys))
+	for name := range sortKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseSortKeys parses keys like "lines", "lines:asc" or "names:desc".
+func parseSortKeys(specs []string, ps *statistics.Params) ([]sortKey, error) {
+	keys := make([]sortKey, 0, len(specs))
+	for _, spec := range specs {
+		name, dir, hasDir := strings.Cut(strings.ToLower(spec), ":")
+		def, ok := sortKeys[name]
+		if !ok {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("unexpected sort key: %s, expected one of %s", name, strings.Join(sortKeyNames(), ", ")),
+			}
+		}
+		if def.enabled != nil && !def.enabled(ps) {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("sort key %s requires %s", name, def.requires),
+			}
+		}
+
+		key := sortKey{Name: name, Desc: def.value != nil}
+		if hasDir {
+			switch dir {
+			case "asc":
+				key.Desc = false
+			case "desc":
+				key.Desc = true
+			default:
+				return nil, utils.ErrorInvalidParameters{
+					Info: fmt.Sprintf("unexpected sort direction of %s: %s, expected asc or desc", name, dir),
+				}
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// computedColumns returns columns of computed keys in the order of keys.
+func computedColumns(keys []sortKey) []column {
+	var columns []column
+	seen := make(map[string]struct{})
+	for _, key := range keys {
+		if _, ok := seen[key.Name]; ok {
+			continue
+		}
+		seen[key.Name] = struct{}{}
+		if col := sortKeys[key.Name].computed; col != nil {
+			columns = append(columns, *col)
+		}
+	}
+	return columns
+}
+
+func sortUnits(units []*statUnit, keys []sortKey) {
+	fullKeys := append([]sortKey{}, keys...)
+	fullKeys = append(fullKeys,
+		sortKey{Name: "lines", Desc: true},
+		sortKey{Name: "commits", Desc: true},
+		sortKey{Name: "files", Desc: true},
+	)
+
+	sort.Slice(units, func(i, j int) bool {
+		for _, key := range fullKeys {
+			value := sortKeys[key.Name].value
+			if value == nil {
+				if units[i].Name != units[j].Name {
+					return (units[i].Name < units[j].Name) != key.Desc
+				}
+				continue
+			}
+			if vi, vj := value(units[i]), value(units[j]); vi != vj {
+				return (vi < vj) != key.Desc
+			}
+		}
+		return units[i].Name < units[j].Name
+	})
+}
+
+// unitValues fills values of units which are shown only on demand.
+type unitValues struct {
+	totals statistics.Totals
+	scorer *statistics.Scorer
+	keys   map[string]struct{}
+}
+
+func newUnitValues(st *statistics.Stat, ps *statistics.Params, keys []sortKey) *unitValues {
+	uv := &unitValues{
+		totals: st.Totals(),
+		keys:   make(map[string]struct{}),
+	}
+	if ps.Score != nil {
+		uv.scorer = statistics.NewScorer(ps.Score, uv.totals)
+	}
+	for _, key := range keys {
+		uv.keys[key.Name] = struct{}{}
+	}
+	return uv
+}
+
+func percent(share float64) *float64 {
+	v := math.Round(share*10000) / 100
+	return &v
+}
+
+func (uv *unitValues) fill(unit *statUnit, user *statistics.StatUser) {
+	if uv.scorer != nil {
+		score := uv.scorer.Score(user)
+		unit.Score = &score
+	}
+	if _, ok := uv.keys["lines_percent"]; ok {
+		unit.LinesPercent = percent(uv.totals.LinesShare(user))
+	}
+	if _, ok := uv.keys["commits_percent"]; ok {
+		unit.CommitsPercent = percent(uv.totals.CommitsShare(user))
+	}
+	if _, ok := uv.keys["files_percent"]; ok {
+		unit.FilesPercent = percent(uv.totals.FilesShare(user))
+	}
+	if _, ok := uv.keys["age"]; ok {
+		age := math.Round(uv.totals.MedianAge(user)*100) / 100
+		unit.Age = &age
+	}
+}
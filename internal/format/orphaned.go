@@ -0,0 +1,57 @@
+package format
+
+import (
+	"github.com/20xygen/git-blame/internal/statistics"
+	"math"
+	"sort"
+)
+
+// OrphanedTable reports the orphaned lines of the repository followed by directories
+// cut at the depth and files whose owners are all inactive, most orphaned lines first.
+func OrphanedTable(st *statistics.Stat, active map[string]struct{}, depth int) *Table {
+	t := &Table{
+		Header: []string{"Scope", "Name", "Lines", "Orphaned", "Orphaned Share"},
+	}
+
+	type entry struct {
+		scope string
+		name  string
+		orph  statistics.Orphaned
+	}
+	row := func(e entry) []any {
+		share := 0.0
+		if e.orph.Lines > 0 {
+			share = math.Round(float64(e.orph.Orphaned)/float64(e.orph.Lines)*10000) / 100
+		}
+		return []any{e.scope, e.name, e.orph.Lines, e.orph.Orphaned, share}
+	}
+
+	t.Append(row(entry{"total", "", statistics.ComputeOrphaned(st.TotalUsers(), active)})...)
+
+	var entries []entry
+	for dir, users := range st.DirUsers(depth) {
+		if orph := statistics.ComputeOrphaned(users, active); orph.All() {
+			entries = append(entries, entry{"directory", dir, orph})
+		}
+	}
+	for p, sf := range st.Files {
+		if orph := statistics.ComputeOrphaned(sf.Users, active); orph.All() {
+			entries = append(entries, entry{"file", p, orph})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].orph.Orphaned != entries[j].orph.Orphaned {
+			return entries[i].orph.Orphaned > entries[j].orph.Orphaned
+		}
+		if entries[i].scope != entries[j].scope {
+			return entries[i].scope == "directory"
+		}
+		return entries[i].name < entries[j].name
+	})
+	for _, e := range entries {
+		t.Append(row(e)...)
+	}
+
+	return t
+}
@@ -0,0 +1,76 @@
+package format
+
+import (
+	"github.com/20xygen/git-blame/internal/statistics"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func millis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+func groupTable(header string, groups []statistics.ProfileGroup) *Table {
+	t := &Table{
+		Header: []string{header, "Files", "Ms", "Bytes"},
+	}
+	for _, g := range groups {
+		t.Append(g.Name, g.Files, millis(g.Duration), g.Size)
+	}
+	return t
+}
+
+// ProfileReport lists the n slowest files to blame followed by totals per
+// extension and per top-level directory.
+func ProfileReport(p *statistics.Profile, n int) (string, error) {
+	slowest := &Table{
+		Header: []string{"Path", "Ms", "Bytes", "Lines"},
+	}
+	for _, ft := range p.Slowest(n) {
+		slowest.Append(ft.Path, millis(ft.Duration), ft.Size, ft.Lines)
+	}
+
+	sections := []struct {
+		title string
+		table *Table
+	}{
+		{"Slowest files", slowest},
+		{"By extension", groupTable("Extension", p.ByExtension())},
+		{"By directory", groupTable("Directory", p.ByDirectory(1))},
+		{"Total", groupTable("Scope", []statistics.ProfileGroup{p.Total()})},
+	}
+
+	var builder strings.Builder
+	for i, section := range sections {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		out, err := RenderTable(section.table, "tabular")
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(section.title + ":\n")
+		builder.WriteString(out)
+	}
+	return builder.String(), nil
+}
+
+// OversizedReport lists files over the size limits with what was done to them.
+func OversizedReport(fls []statistics.OversizedFile) (string, error) {
+	t := &Table{
+		Header: []string{"Path", "Reason", "Bytes", "Lines", "Action"},
+	}
+	for _, fl := range fls {
+		lines := "?"
+		if fl.Lines >= 0 {
+			lines = strconv.Itoa(fl.Lines)
+		}
+		t.Append(fl.Path, fl.Reason, fl.Size, lines, fl.Action)
+	}
+	out, err := RenderTable(t, "tabular")
+	if err != nil {
+		return "", err
+	}
+	return "Oversized files:\n" + out, nil
+}
@@ -0,0 +1,158 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/statistics"
+	"github.com/20xygen/git-blame/internal/utils"
+	"io"
+	"time"
+)
+
+const (
+	textProgressPeriod = 100 * time.Millisecond
+	jsonProgressPeriod = time.Second
+)
+
+// NewProgress returns a progress reporter writing to w in the mode, one of
+// 'text', 'json' or 'none'.
+func NewProgress(w io.Writer, mode string) (statistics.Progress, error) {
+	switch mode {
+	case "text":
+		return &textProgress{w: w}, nil
+	case "json":
+		return &jsonProgress{w: w}, nil
+	case "none":
+		return nil, nil
+	}
+	return nil, utils.ErrorInvalidParameters{
+		Info: fmt.Sprintf("unexpected progress mode: %q", mode),
+	}
+}
+
+// counter tracks processed files and estimates the remaining time.
+type counter struct {
+	total    int
+	done     int
+	start    time.Time
+	updated  time.Time
+	finished bool
+}
+
+func (c *counter) begin(total int) {
+	c.total = total
+	c.done = 0
+	c.start = time.Now()
+	c.finished = false
+}
+
+// end tells whether a started run is finished for the first time, Finish is
+// also called at exit.
+func (c *counter) end() bool {
+	if c.start.IsZero() || c.finished {
+		return false
+	}
+	c.finished = true
+	return true
+}
+
+// next counts the file and tells whether the period since the last update passed.
+func (c *counter) next(period time.Duration) bool {
+	c.done++
+	now := time.Now()
+	if now.Sub(c.updated) < period {
+		return false
+	}
+	c.updated = now
+	return true
+}
+
+func (c *counter) elapsed() time.Duration {
+	return time.Since(c.start)
+}
+
+// eta extrapolates the time per finished file, unknown before the first one finishes.
+func (c *counter) eta() (time.Duration, bool) {
+	finished := c.done - 1
+	if finished <= 0 {
+		return 0, false
+	}
+	return c.elapsed() / time.Duration(finished) * time.Duration(c.total-finished), true
+}
+
+// textProgress redraws a single status line, for terminals.
+type textProgress struct {
+	w io.Writer
+	counter
+}
+
+func (p *textProgress) Start(total int) {
+	p.begin(total)
+}
+
+func (p *textProgress) File(path string) {
+	if !p.next(textProgressPeriod) && p.done != p.total {
+		return
+	}
+
+	eta := "?"
+	if d, ok := p.eta(); ok {
+		eta = d.Round(time.Second).String()
+	}
+	_, _ = fmt.Fprintf(p.w, "\r\033[K[%d/%d] %d%% ETA %s %s",
+		p.done, p.total, p.done*100/max(p.total, 1), eta, path)
+}
+
+func (p *textProgress) Finish() {
+	if !p.end() {
+		return
+	}
+	_, _ = fmt.Fprint(p.w, "\r\033[K")
+}
+
+// jsonProgress writes periodic events as JSON lines, for scripts.
+type jsonProgress struct {
+	w io.Writer
+	counter
+}
+
+type progressEvent struct {
+	Event     string `json:"event"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	File      string `json:"file,omitempty"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+	EtaMs     *int64 `json:"eta_ms,omitempty"`
+}
+
+func (p *jsonProgress) emit(ev progressEvent) {
+	ev.Total = p.total
+	ev.ElapsedMs = p.elapsed().Milliseconds()
+	data, _ := json.Marshal(ev)
+	_, _ = fmt.Fprintln(p.w, string(data))
+}
+
+func (p *jsonProgress) Start(total int) {
+	p.begin(total)
+	p.updated = p.start
+	p.emit(progressEvent{Event: "start"})
+}
+
+func (p *jsonProgress) File(path string) {
+	if !p.next(jsonProgressPeriod) {
+		return
+	}
+	ev := progressEvent{Event: "progress", Done: p.done - 1, File: path}
+	if d, ok := p.eta(); ok {
+		ms := d.Milliseconds()
+		ev.EtaMs = &ms
+	}
+	p.emit(ev)
+}
+
+func (p *jsonProgress) Finish() {
+	if !p.end() {
+		return
+	}
+	p.emit(progressEvent{Event: "finish", Done: p.total})
+}
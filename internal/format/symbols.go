@@ -0,0 +1,20 @@
+package format
+
+import (
+	"github.com/20xygen/git-blame/internal/statistics"
+	"math"
+)
+
+// SymbolTable reports the primary author of every symbol in file order.
+func SymbolTable(stats []*statistics.SymbolStat) *Table {
+	t := &Table{
+		Header: []string{"Path", "Symbol", "Kind", "Start", "End", "Lines", "Primary Author", "Share"},
+	}
+
+	for _, ss := range stats {
+		top, share := ss.Primary()
+		t.Append(ss.Path, ss.Name, ss.Kind, ss.Start, ss.End, ss.Lines(), top, math.Round(share*10000)/100)
+	}
+
+	return t
+}
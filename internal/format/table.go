@@ -0,0 +1,198 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Table is a report rendered in any of the output formats.
+// JSON keys are the lower-cased headers with spaces replaced by underscores.
+type Table struct {
+	Header []string
+	Rows   [][]any
+}
+
+func (t *Table) Append(row ...any) {
+	t.Rows = append(t.Rows, row)
+}
+
+func RenderTable(t *Table, outFormat string) (string, error) {
+	switch outFormat {
+	case "tabular":
+		return tableTabular(t), nil
+	case "pretty":
+		return tablePretty(t), nil
+	case "csv":
+		return tableCSV(t)
+	case "json":
+		return tableJSON(t)
+	case "json-lines":
+		return tableJSONLines(t)
+	default:
+		return "", utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("unexpected format: %q", outFormat),
+		}
+	}
+}
+
+func cellString(v any) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', 2, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+func rowStrings(row []any) []string {
+	strs := make([]string, 0, len(row))
+	for _, v := range row {
+		strs = append(strs, cellString(v))
+	}
+	return strs
+}
+
+func tableTabular(t *Table) string {
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 1, ' ', 0)
+
+	_, _ = fmt.Fprintln(writer, strings.Join(t.Header, "\t"))
+	for _, row := range t.Rows {
+		_, _ = fmt.Fprintln(writer, strings.Join(rowStrings(row), "\t"))
+	}
+
+	_ = writer.Flush()
+	return builder.String()
+}
+
+func tablePretty(t *Table) string {
+	var builder strings.Builder
+
+	tw := table.NewWriter()
+	tw.SetOutputMirror(&builder)
+	header := make(table.Row, 0, len(t.Header))
+	for _, h := range t.Header {
+		header = append(header, h)
+	}
+	tw.AppendHeader(header)
+	for _, row := range t.Rows {
+		r := make(table.Row, 0, len(row))
+		for _, v := range row {
+			if f, ok := v.(float64); ok {
+				r = append(r, cellString(f))
+			} else {
+				r = append(r, v)
+			}
+		}
+		tw.AppendRow(r)
+	}
+	tw.Render()
+
+	return builder.String()
+}
+
+func tableCSV(t *Table) (string, error) {
+	var builder strings.Builder
+	writer := csv.NewWriter(&builder)
+
+	if err := writer.Write(t.Header); err != nil {
+		return "", err
+	}
+	for _, row := range t.Rows {
+		if err := writer.Write(rowStrings(row)); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	return builder.String(), writer.Error()
+}
+
+// tableObject keeps the column order when marshalled.
+type tableObject struct {
+	keys []string
+	vals []any
+}
+
+func (o tableObject) MarshalJSON() ([]byte, error) {
+	var builder strings.Builder
+	builder.WriteString("{")
+	for i, k := range o.keys {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		key, err := marshal(k, false)
+		if err != nil {
+			return nil, err
+		}
+		val, err := marshal(o.vals[i], false)
+		if err != nil {
+			return nil, err
+		}
+		builder.Write(key)
+		builder.WriteString(":")
+		builder.Write(val)
+	}
+	builder.WriteString("}")
+	return []byte(builder.String()), nil
+}
+
+// marshal does not escape HTML characters, which are common in table headers like "<1m".
+func marshal(v any, indent bool) ([]byte, error) {
+	var builder bytes.Buffer
+	enc := json.NewEncoder(&builder)
+	enc.SetEscapeHTML(false)
+	if indent {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(builder.Bytes(), []byte("\n")), nil
+}
+
+func (t *Table) objects() []tableObject {
+	keys := make([]string, 0, len(t.Header))
+	for _, h := range t.Header {
+		keys = append(keys, strings.ReplaceAll(strings.ToLower(h), " ", "_"))
+	}
+
+	objs := make([]tableObject, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		objs = append(objs, tableObject{
+			keys: keys,
+			vals: row,
+		})
+	}
+	return objs
+}
+
+func tableJSON(t *Table) (string, error) {
+	jsonData, err := marshal(t.objects(), true)
+	if err != nil {
+		return "", utils.ErrorJSONSerialization{}
+	}
+	return string(jsonData), nil
+}
+
+func tableJSONLines(t *Table) (string, error) {
+	var builder strings.Builder
+
+	for _, obj := range t.objects() {
+		jsonData, err := marshal(obj, false)
+		if err != nil {
+			return "", utils.ErrorJSONSerialization{}
+		}
+		builder.Write(jsonData)
+		builder.WriteString("\n")
+	}
+
+	return builder.String(), nil
+}
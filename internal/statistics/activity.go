@@ -0,0 +1,137 @@
+package statistics
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/commands"
+	"github.com/20xygen/git-blame/pkg/files"
+	"github.com/20xygen/git-blame/pkg/parsing"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadActive loads active identities from a file, one name per line.
+// Empty lines and lines starting with '#' are ignored.
+func ReadActive(path string) (map[string]struct{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.ErrorConfigFile{
+			E: err,
+		}
+	}
+
+	active := make(map[string]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		active[name] = struct{}{}
+	}
+	return active, scanner.Err()
+}
+
+// ActiveAuthors returns identities with a commit within the period before the
+// latest analyzed revision in any of the repositories or, when recursing, their
+// submodules.
+func ActiveAuthors(st *Stat, ps *Params, period time.Duration) (map[string]struct{}, error) {
+	since := st.Time - int64(period/time.Second)
+
+	active := make(map[string]struct{})
+	for _, repo := range ps.Repositories {
+		if err := addActive(active, ps, repo, since); err != nil {
+			return nil, err
+		}
+	}
+	return active, nil
+}
+
+func addActive(active map[string]struct{}, ps *Params, repo Repository, since int64) error {
+	// co-authors are active as long as they are credited
+	coauthors := ps.Coauthors != CoauthorsIgnore && !ps.UseCommitter
+	out, err := commands.GitAuthorTimes(repo.Path, repo.Revision, since, ps.UseCommitter, coauthors)
+	if err != nil {
+		return err
+	}
+	for _, ln := range strings.Split(string(out), "\n") {
+		fields := strings.Split(ln, "\x00")
+		if len(fields) < 2 {
+			continue
+		}
+		t, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return commands.ErrorInvalidGitLogOutput{}
+		}
+		if t < since {
+			continue
+		}
+		if fields[1] != "" {
+			active[fields[1]] = struct{}{}
+		}
+		for _, co := range fields[2:] {
+			if name := parseIdentity(co).Name; name != "" {
+				active[name] = struct{}{}
+			}
+		}
+	}
+
+	if !ps.RecurseSubmodules {
+		return nil
+	}
+	var subs []files.Submodule
+	if ps.Worktree {
+		_, subs, err = files.GetDirSubmodules(repo.Path)
+	} else {
+		_, subs, err = files.GetDirGitSubmodules(repo.Path, repo.Revision)
+	}
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if !sub.CheckedOut(repo.Path) {
+			continue
+		}
+		err = addActive(active, ps, Repository{
+			Name:     path.Join(repo.Name, sub.Path),
+			Path:     filepath.Join(repo.Path, sub.Path),
+			Revision: sub.Hash,
+		}, since)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Orphaned is the part of lines owned by inactive identities.
+type Orphaned struct {
+	Lines    int
+	Orphaned int
+}
+
+// All reports whether every owner is inactive.
+func (o Orphaned) All() bool {
+	return o.Lines > 0 && o.Lines == o.Orphaned
+}
+
+// ComputeOrphaned counts lines of inactive owners. Boundary and uncommitted
+// lines have no owner to leave and are never orphaned.
+func ComputeOrphaned(users map[string]int, active map[string]struct{}) Orphaned {
+	var o Orphaned
+	for name, n := range users {
+		o.Lines += n
+		if name == BoundaryName || name == parsing.NotCommittedAuthor {
+			continue
+		}
+		if _, ok := active[name]; !ok {
+			o.Orphaned += n
+		}
+	}
+	return o
+}
@@ -0,0 +1,163 @@
+package statistics
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	day   = 24 * time.Hour
+	month = 30 * day
+	year  = 365 * day
+)
+
+// AgeBucket holds lines younger than Max and not younger than the previous bucket.
+// The last bucket has no upper bound.
+type AgeBucket struct {
+	Label string
+	Max   time.Duration
+}
+
+// Ages is the distribution of line ages relative to the analyzed revision.
+type Ages struct {
+	Lines   int
+	Buckets []int
+	Median  time.Duration
+}
+
+func parseAge(s string) (time.Duration, error) {
+	if len(s) < 2 {
+		return 0, fmt.Errorf("bad age %q", s)
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("bad age %q", s)
+	}
+	switch s[len(s)-1] {
+	case 'd':
+		return time.Duration(n) * day, nil
+	case 'w':
+		return time.Duration(n) * 7 * day, nil
+	case 'm':
+		return time.Duration(n) * month, nil
+	case 'y':
+		return time.Duration(n) * year, nil
+	default:
+		return 0, fmt.Errorf("bad age unit in %q", s)
+	}
+}
+
+// ParseBuckets turns ascending bounds like "1m,6m,1y" (units d, w, m, y) into buckets
+// "<1m", "1m-6m", "6m-1y", ">1y".
+func ParseBuckets(bounds []string) ([]AgeBucket, error) {
+	buckets := make([]AgeBucket, 0, len(bounds)+1)
+	prev := ""
+	var prevMax time.Duration
+	for _, b := range bounds {
+		b = strings.TrimSpace(b)
+		mx, err := parseAge(b)
+		if err != nil {
+			return nil, utils.ErrorInvalidParameters{
+				Info: err.Error(),
+			}
+		}
+		if mx <= prevMax {
+			return nil, utils.ErrorInvalidParameters{
+				Info: "age buckets must be ascending",
+			}
+		}
+
+		label := "<" + b
+		if prev != "" {
+			label = prev + "-" + b
+		}
+		buckets = append(buckets, AgeBucket{
+			Label: label,
+			Max:   mx,
+		})
+		prev, prevMax = b, mx
+	}
+
+	label := "any"
+	if prev != "" {
+		label = ">" + prev
+	}
+	return append(buckets, AgeBucket{Label: label}), nil
+}
+
+// ComputeAges distributes lines by commit timestamps into the buckets.
+func ComputeAges(times map[int64]int, now int64, buckets []AgeBucket) Ages {
+	ages := Ages{
+		Buckets: make([]int, len(buckets)),
+	}
+
+	stamps := make([]int64, 0, len(times))
+	for t, n := range times {
+		stamps = append(stamps, t)
+		ages.Lines += n
+
+		age := time.Duration(max(now-t, 0)) * time.Second
+		i := sort.Search(len(buckets)-1, func(i int) bool {
+			return age < buckets[i].Max
+		})
+		ages.Buckets[i] += n
+	}
+
+	// the newest lines go first, the median is the middle line
+	sort.Slice(stamps, func(i, j int) bool {
+		return stamps[i] > stamps[j]
+	})
+	mid := (ages.Lines - 1) / 2
+	for _, t := range stamps {
+		if mid < times[t] {
+			ages.Median = time.Duration(max(now-t, 0)) * time.Second
+			break
+		}
+		mid -= times[t]
+	}
+
+	return ages
+}
+
+// dirOf cuts the directory of the path at the depth.
+func dirOf(p string, depth int) string {
+	parts := strings.Split(path.Dir(p), "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return path.Join(parts...)
+}
+
+// DirTimes merges commit timestamps of files by directories cut at the depth.
+// Files above the depth are accounted to their own directory, "." for the root.
+func (st *Stat) DirTimes(depth int) map[string]map[int64]int {
+	dirs := make(map[string]map[int64]int)
+	for p, sf := range st.Files {
+		dir := dirOf(p, depth)
+		times, ok := dirs[dir]
+		if !ok {
+			times = make(map[int64]int)
+			dirs[dir] = times
+		}
+		for t, n := range sf.Times {
+			times[t] += n
+		}
+	}
+	return dirs
+}
+
+// TotalTimes merges commit timestamps of all files.
+func (st *Stat) TotalTimes() map[int64]int {
+	times := make(map[int64]int)
+	for _, sf := range st.Files {
+		for t, n := range sf.Times {
+			times[t] += n
+		}
+	}
+	return times
+}
@@ -0,0 +1,127 @@
+package statistics
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/commands"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// Baseline is a snapshot of previous results. Files unchanged since its
+// revisions are taken from it instead of being blamed again.
+type Baseline struct {
+	snapshot *Snapshot
+	changed  map[string]struct{} // paths changed, added, renamed or deleted
+	kept     []string
+}
+
+// NewBaseline checks the snapshot was collected with the same parameters and
+// finds files changed since, revisions of ps must be resolved.
+func NewBaseline(s *Snapshot, ps *Params) (*Baseline, error) {
+	if ps.Worktree {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "--baseline needs revisions, not the working copy",
+		}
+	}
+	if ps.RecurseSubmodules {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "--baseline doesn't support --recurse-submodules",
+		}
+	}
+	if !s.Params.equal(snapshotParams(ps)) {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "baseline was collected with other parameters",
+		}
+	}
+
+	revisions := make(map[string]SnapshotRepository)
+	for _, repo := range s.Repositories {
+		revisions[repo.Name] = repo
+	}
+	if len(revisions) != len(ps.Repositories) {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "baseline was collected for other repositories",
+		}
+	}
+
+	b := &Baseline{
+		snapshot: s,
+		changed:  make(map[string]struct{}),
+	}
+	for _, repo := range ps.Repositories {
+		base, ok := revisions[repo.Name]
+		if !ok || base.Since != repo.Since {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("baseline was collected for other repositories, %s differs", repo.Name),
+			}
+		}
+		if base.Revision == repo.Revision {
+			continue
+		}
+
+		paths, err := changedPaths(repo.Path, base.Revision, repo.Revision)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range paths {
+			if len(ps.Repositories) > 1 {
+				p = path.Join(repo.Name, p)
+			}
+			b.changed[p] = struct{}{}
+		}
+	}
+	slog.Info("Loaded baseline", "files", len(s.Files), "changed", len(b.changed))
+	return b, nil
+}
+
+// changedPaths lists paths changed between the revisions. Both sides of
+// renames and copies are listed.
+func changedPaths(repo, from, to string) ([]string, error) {
+	out, err := commands.GitDiffNameStatus(repo, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Split(string(bytes.TrimSuffix(out, []byte{0})), "\x00")
+	if len(fields) == 1 && fields[0] == "" {
+		return nil, nil
+	}
+
+	var paths []string
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		n := 1
+		if strings.HasPrefix(status, "R") || strings.HasPrefix(status, "C") {
+			n = 2
+		}
+		if status == "" || i+n >= len(fields) {
+			return nil, commands.ErrorInvalidGitDiffOutput{}
+		}
+		paths = append(paths, fields[i+1:i+1+n]...)
+		i += 1 + n
+	}
+	return paths, nil
+}
+
+// keep tells whether the file is taken from the baseline.
+func (b *Baseline) keep(path string) bool {
+	if _, ok := b.changed[path]; ok {
+		return false
+	}
+	if _, ok := b.snapshot.Files[path]; !ok {
+		return false
+	}
+	b.kept = append(b.kept, path)
+	return true
+}
+
+// addKept adds files taken from the baseline to statistics.
+func (b *Baseline) addKept(st *Stat) {
+	for _, p := range b.kept {
+		st.AddFile(p, b.snapshot.statFile(b.snapshot.Files[p], st))
+	}
+	slog.Info("Reused baseline files", "files", len(b.kept))
+}
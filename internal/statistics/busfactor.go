@@ -0,0 +1,71 @@
+package statistics
+
+import "sort"
+
+// Ownership is the line distribution between authors of a file, a directory or a repository.
+type Ownership struct {
+	Lines     int
+	BusFactor int     // minimal number of authors whose departure orphans more than the threshold
+	Top       string  // author owning most of the lines
+	TopShare  float64 // share of lines owned by Top
+}
+
+// ComputeOwnership finds the bus factor for the threshold share of lines in (0, 1].
+func ComputeOwnership(users map[string]int, threshold float64) Ownership {
+	names := make([]string, 0, len(users))
+	var own Ownership
+	for name, n := range users {
+		names = append(names, name)
+		own.Lines += n
+	}
+	if own.Lines == 0 {
+		return own
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if users[names[i]] != users[names[j]] {
+			return users[names[i]] > users[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	own.Top = names[0]
+	own.TopShare = float64(users[names[0]]) / float64(own.Lines)
+
+	orphaned := 0
+	for _, name := range names {
+		if float64(orphaned) > threshold*float64(own.Lines) {
+			break
+		}
+		orphaned += users[name]
+		own.BusFactor++
+	}
+
+	return own
+}
+
+// DirUsers merges author lines of files by directories cut at the depth.
+func (st *Stat) DirUsers(depth int) map[string]map[string]int {
+	dirs := make(map[string]map[string]int)
+	for p, sf := range st.Files {
+		dir := dirOf(p, depth)
+		users, ok := dirs[dir]
+		if !ok {
+			users = make(map[string]int)
+			dirs[dir] = users
+		}
+		for name, n := range sf.Users {
+			users[name] += n
+		}
+	}
+	return dirs
+}
+
+// TotalUsers returns lines of every author.
+func (st *Stat) TotalUsers() map[string]int {
+	users := make(map[string]int, len(st.Users))
+	for name, usr := range st.Users {
+		users[name] = usr.Lines
+	}
+	return users
+}
@@ -0,0 +1,61 @@
+package statistics
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"regexp"
+	"strings"
+)
+
+// ClassOther holds lines of commits matching no rule.
+const ClassOther = "other"
+
+var conventionalRe = regexp.MustCompile(`^(?i)(feat|fix|refactor|perf|docs|test|build|ci|style|chore|revert)(\([^)]*\))?!?:`)
+
+// ClassRule assigns the class to commits with subjects matching the regex.
+type ClassRule struct {
+	Name string
+	Re   *regexp.Regexp
+}
+
+// ParseClassRules parses rules like "fix=(?i)\bfix". Rules are tried in order.
+func ParseClassRules(specs []string) ([]ClassRule, error) {
+	rules := make([]ClassRule, 0, len(specs))
+	for _, spec := range specs {
+		name, expr, ok := strings.Cut(spec, "=")
+		if !ok || name == "" {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("bad class rule %q, expected name=regex", spec),
+			}
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("bad class rule %q: %v", spec, err),
+			}
+		}
+		rules = append(rules, ClassRule{
+			Name: name,
+			Re:   re,
+		})
+	}
+	return rules, nil
+}
+
+// classify returns the class of the commit subject. Without rules the
+// conventional commit type is used.
+func classify(subject string, rules []ClassRule) string {
+	if len(rules) == 0 {
+		if m := conventionalRe.FindStringSubmatch(subject); m != nil {
+			return strings.ToLower(m[1])
+		}
+		return ClassOther
+	}
+
+	for _, rule := range rules {
+		if rule.Re.MatchString(subject) {
+			return rule.Name
+		}
+	}
+	return ClassOther
+}
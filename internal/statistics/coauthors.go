@@ -0,0 +1,107 @@
+package statistics
+
+import (
+	"github.com/20xygen/git-blame/pkg/commands"
+	"github.com/20xygen/git-blame/pkg/parsing"
+	"strings"
+)
+
+// Modes of crediting lines to co-authors from Co-authored-by trailers.
+const (
+	CoauthorsIgnore = "ignore" // only the author is credited
+	CoauthorsSplit  = "split"  // lines are split evenly between the author and co-authors
+	CoauthorsFull   = "full"   // every co-author is credited with all lines
+)
+
+type identity struct {
+	Name  string
+	Email string
+}
+
+// credit is the part of commit lines attributed to an identity.
+type credit struct {
+	identity
+	Lines int
+}
+
+// parseIdentity splits "Name <email>".
+func parseIdentity(s string) identity {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndex(s, " <"); i >= 0 && strings.HasSuffix(s, ">") {
+		return identity{
+			Name:  s[:i],
+			Email: s[i+2 : len(s)-1],
+		}
+	}
+	return identity{Name: s}
+}
+
+// commitInfo is the part of a commit message used for enrichment.
+type commitInfo struct {
+	Subject   string
+	Coauthors []identity
+}
+
+// commitCache keeps commit infos so every commit message is read once.
+type commitCache map[string]*commitInfo
+
+func (c commitCache) get(repo Repository, hash string) (*commitInfo, error) {
+	if hash == parsing.NotCommittedHash {
+		return &commitInfo{}, nil
+	}
+	if ci, ok := c[hash]; ok {
+		return ci, nil
+	}
+
+	out, err := commands.GitCommitInfo(repo.Path, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, trailers, _ := strings.Cut(string(out), "\x00")
+	ci := &commitInfo{
+		Subject: subject,
+	}
+	for _, ln := range strings.Split(trailers, "\n") {
+		if strings.TrimSpace(ln) != "" {
+			ci.Coauthors = append(ci.Coauthors, parseIdentity(ln))
+		}
+	}
+	c[hash] = ci
+	return ci, nil
+}
+
+// credits distributes lines between the author and co-authors. Co-authors are
+// deduplicated by name and email, including the author repeated in trailers. Remainders
+// of an even split go to the author first, then to co-authors in trailer order.
+func credits(lines int, author identity, coauthors []identity, mode string) []credit {
+	people := []identity{author}
+	names := map[string]struct{}{author.Name: {}}
+	emails := map[string]struct{}{strings.ToLower(author.Email): {}}
+	for _, co := range coauthors {
+		_, sameName := names[co.Name]
+		_, sameEmail := emails[strings.ToLower(co.Email)]
+		if sameName || sameEmail && co.Email != "" {
+			continue
+		}
+		names[co.Name] = struct{}{}
+		emails[strings.ToLower(co.Email)] = struct{}{}
+		people = append(people, co)
+	}
+	if mode == CoauthorsIgnore || len(people) == 1 {
+		return []credit{{author, lines}}
+	}
+
+	res := make([]credit, 0, len(people))
+	for i, p := range people {
+		share := lines
+		if mode == CoauthorsSplit {
+			share = lines / len(people)
+			if i < lines%len(people) {
+				share++
+			}
+		}
+		res = append(res, credit{p, share})
+	}
+	return res
+}
@@ -0,0 +1,66 @@
+package statistics
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCredits(t *testing.T) {
+	alice := identity{Name: "Alice", Email: "alice@example.com"}
+	bob := identity{Name: "Bob", Email: "bob@example.com"}
+
+	for _, tc := range []struct {
+		name      string
+		lines     int
+		coauthors []identity
+		mode      string
+		expected  []credit
+	}{
+		{
+			name:      "ignore",
+			lines:     5,
+			coauthors: []identity{bob},
+			mode:      CoauthorsIgnore,
+			expected:  []credit{{alice, 5}},
+		},
+		{
+			name:      "split remainder",
+			lines:     5,
+			coauthors: []identity{bob},
+			mode:      CoauthorsSplit,
+			expected:  []credit{{alice, 3}, {bob, 2}},
+		},
+		{
+			name:      "full",
+			lines:     5,
+			coauthors: []identity{bob},
+			mode:      CoauthorsFull,
+			expected:  []credit{{alice, 5}, {bob, 5}},
+		},
+		{
+			name:      "author in trailers",
+			lines:     4,
+			coauthors: []identity{{Name: "alice", Email: "Alice@Example.com"}, bob},
+			mode:      CoauthorsSplit,
+			expected:  []credit{{alice, 2}, {bob, 2}},
+		},
+		{
+			name:      "repeated co-author",
+			lines:     4,
+			coauthors: []identity{bob, {Name: "Bobby", Email: "bob@example.com"}, bob},
+			mode:      CoauthorsFull,
+			expected:  []credit{{alice, 4}, {bob, 4}},
+		},
+		{
+			name:      "split without lines for co-authors",
+			lines:     1,
+			coauthors: []identity{bob},
+			mode:      CoauthorsSplit,
+			expected:  []credit{{alice, 1}, {bob, 0}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, credits(tc.lines, alice, tc.coauthors, tc.mode))
+		})
+	}
+}
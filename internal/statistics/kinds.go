@@ -0,0 +1,172 @@
+package statistics
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/files"
+	"github.com/20xygen/git-blame/pkg/parsing"
+	"sort"
+	"strings"
+)
+
+// Kinds of lines counted by --count.
+const (
+	CountAll     = "all"
+	CountCode    = "code"
+	CountComment = "comment"
+	CountBlank   = "blank"
+)
+
+// LineKinds is the number of code, comment and blank lines.
+type LineKinds struct {
+	Code    int `json:"code"`
+	Comment int `json:"comment"`
+	Blank   int `json:"blank"`
+}
+
+func (lk *LineKinds) Add(other LineKinds) {
+	lk.Code += other.Code
+	lk.Comment += other.Comment
+	lk.Blank += other.Blank
+}
+
+// Count returns the number of lines of the kind, all lines for CountAll.
+func (lk LineKinds) Count(kind string) int {
+	switch kind {
+	case CountCode:
+		return lk.Code
+	case CountComment:
+		return lk.Comment
+	case CountBlank:
+		return lk.Blank
+	}
+	return lk.Code + lk.Comment + lk.Blank
+}
+
+// take removes up to n lines from lk, code first, then comments and blank lines.
+func (lk *LineKinds) take(n int) LineKinds {
+	var res LineKinds
+	res.Code = min(n, lk.Code)
+	res.Comment = min(n-res.Code, lk.Comment)
+	res.Blank = min(n-res.Code-res.Comment, lk.Blank)
+	lk.Code -= res.Code
+	lk.Comment -= res.Comment
+	lk.Blank -= res.Blank
+	return res
+}
+
+// splitKinds divides kinds of commit lines between credits of all its lines,
+// so kinds of every credit sum up to its lines. Every credit of the full mode
+// gets all kinds.
+func splitKinds(lk LineKinds, crs []credit, mode string) []LineKinds {
+	res := make([]LineKinds, len(crs))
+	for i, cr := range crs {
+		if mode == CoauthorsFull {
+			res[i] = lk
+		} else {
+			res[i] = lk.take(cr.Lines)
+		}
+	}
+	return res
+}
+
+func validateCount(kind string) error {
+	if !utils.Contains([]string{CountAll, CountCode, CountComment, CountBlank}, kind) {
+		return utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("unexpected line kind: %q", kind),
+		}
+	}
+	return nil
+}
+
+// lineClassifier tells code, comment and blank lines of a file apart. Lines
+// are fed in order since block comments span several lines. String literals
+// are not parsed, so comment markers inside them may confuse it.
+type lineClassifier struct {
+	syntax files.CommentSyntax
+	end    string // end of the open block comment
+}
+
+// blockStart returns the earliest block comment start in s.
+func (c *lineClassifier) blockStart(s string) (int, [2]string) {
+	at, block := -1, [2]string{}
+	for _, b := range c.syntax.Block {
+		if i := strings.Index(s, b[0]); i >= 0 && (at < 0 || i < at) {
+			at, block = i, b
+		}
+	}
+	return at, block
+}
+
+// lineStart returns the earliest line comment start in s.
+func (c *lineClassifier) lineStart(s string) int {
+	at := -1
+	for _, prefix := range c.syntax.Line {
+		if i := strings.Index(s, prefix); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+	return at
+}
+
+func (c *lineClassifier) classify(line string) LineKinds {
+	s := strings.TrimSpace(line)
+	if s == "" {
+		return LineKinds{Blank: 1}
+	}
+
+	code := false
+	for s != "" {
+		if c.end != "" {
+			i := strings.Index(s, c.end)
+			if i < 0 {
+				break
+			}
+			s = strings.TrimSpace(s[i+len(c.end):])
+			c.end = ""
+			continue
+		}
+
+		// Block starts go first as they may begin with a line prefix, e.g. "--[[" in Lua.
+		bi, block := c.blockStart(s)
+		if bi == 0 {
+			s = s[len(block[0]):]
+			c.end = block[1]
+			continue
+		}
+		li := c.lineStart(s)
+		if li == 0 {
+			break
+		}
+
+		code = true
+		if bi < 0 || li >= 0 && li < bi {
+			break
+		}
+		s = s[bi+len(block[0]):]
+		c.end = block[1]
+	}
+
+	if code {
+		return LineKinds{Code: 1}
+	}
+	return LineKinds{Comment: 1}
+}
+
+// commitKinds returns kinds of lines per commit of the blamed file. Files of
+// languages without known comment syntax consist of code and blank lines.
+func commitKinds(bo *parsing.BlameOutput, syntax files.CommentSyntax) map[string]LineKinds {
+	lines := append([]*parsing.Line{}, bo.Lines...)
+	sort.Slice(lines, func(i, j int) bool {
+		return lines[i].CurPos < lines[j].CurPos
+	})
+
+	c := &lineClassifier{syntax: syntax}
+	kinds := make(map[string]LineKinds)
+	for _, ln := range lines {
+		lk := kinds[ln.Com.Hash]
+		lk.Add(c.classify(ln.Content))
+		kinds[ln.Com.Hash] = lk
+	}
+	return kinds
+}
@@ -0,0 +1,17 @@
+package statistics
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplitKinds(t *testing.T) {
+	lk := LineKinds{Code: 3, Comment: 1, Blank: 1}
+	crs := []credit{{Lines: 3}, {Lines: 2}}
+
+	require.Equal(t, []LineKinds{
+		{Code: 3},
+		{Comment: 1, Blank: 1},
+	}, splitKinds(lk, crs, CoauthorsSplit))
+	require.Equal(t, []LineKinds{lk, lk}, splitKinds(lk, crs, CoauthorsFull))
+}
@@ -0,0 +1,217 @@
+package statistics
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/commands"
+	"github.com/20xygen/git-blame/pkg/files"
+	"github.com/20xygen/git-blame/pkg/parsing"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// What is done to files over the limits.
+const (
+	OversizedSkip       = "skip"
+	OversizedLastCommit = "last-commit"
+)
+
+// OversizedFile is a file over the limits, which was not blamed.
+type OversizedFile struct {
+	Path   string
+	Reason string // "size" or "lines"
+	Size   int64
+	Lines  int // -1 if not counted
+	Action string
+}
+
+// Limits keep huge files, like generated dumps, from being blamed. Such files
+// are skipped or all their lines are attributed to the last commit changing them.
+type Limits struct {
+	MaxSize  int64 // bytes, 0 for no limit
+	MaxLines int   // 0 for no limit
+	Action   string
+
+	Files []OversizedFile // filled during collection
+
+	lines map[*files.File]int // lines of files attributed to last commits
+}
+
+func NewLimits(maxSize int64, maxLines int, action string) (*Limits, error) {
+	if maxSize < 0 || maxLines < 0 {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "file limits must not be negative",
+		}
+	}
+	if !utils.Contains([]string{OversizedSkip, OversizedLastCommit}, action) {
+		return nil, utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("unexpected oversized files action: %q", action),
+		}
+	}
+	if maxSize == 0 && maxLines == 0 {
+		return nil, nil
+	}
+	return &Limits{
+		MaxSize:  maxSize,
+		MaxLines: maxLines,
+		Action:   action,
+		lines:    make(map[*files.File]int),
+	}, nil
+}
+
+// ParseSize parses sizes like "512", "200K", "10M" or "1G".
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	mult := int64(1)
+	for i, suffix := range []string{"K", "M", "G"} {
+		if strings.HasSuffix(s, suffix) || strings.HasSuffix(s, suffix+"B") {
+			s = strings.TrimSuffix(strings.TrimSuffix(s, "B"), suffix)
+			mult = int64(1) << (10 * (i + 1))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("bad size %q", size),
+		}
+	}
+	return n * mult, nil
+}
+
+func countLines(fl *files.File, repo Repository) (int, error) {
+	var r io.ReadCloser
+	var err error
+	if fl.Hash == "" {
+		r, err = os.Open(fl.Path())
+	} else {
+		r, err = commands.GitBlob(repo.Path, fl.Hash)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := files.CountLines(r)
+	if errC := r.Close(); err == nil {
+		err = errC
+	}
+	return n, err
+}
+
+// check records the file if it is over the limits and tells whether to skip it.
+func (l *Limits) check(fl *files.File, repo Repository, path string) (bool, error) {
+	reason := ""
+	lines := -1
+	if l.MaxSize > 0 && fl.Size > l.MaxSize {
+		reason = "size"
+	} else if l.MaxLines > 0 {
+		n, err := countLines(fl, repo)
+		if err != nil {
+			return false, err
+		}
+		lines = n
+		if lines > l.MaxLines {
+			reason = "lines"
+		}
+	}
+	if reason == "" {
+		return false, nil
+	}
+
+	if l.Action == OversizedLastCommit && lines < 0 {
+		n, err := countLines(fl, repo)
+		if err != nil {
+			return false, err
+		}
+		lines = n
+	}
+
+	l.Files = append(l.Files, OversizedFile{
+		Path:   path,
+		Reason: reason,
+		Size:   fl.Size,
+		Lines:  lines,
+		Action: l.Action,
+	})
+	if l.Action == OversizedSkip {
+		return true, nil
+	}
+	l.lines[fl] = lines
+	return false, nil
+}
+
+// approximated tells whether the file lines go to its last commit.
+func (l *Limits) approximated(fl *files.File) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.lines[fl]
+	return ok
+}
+
+// lastCommitBlame attributes all lines of the file to the last commit changing
+// it, without contents of lines. With a range, lines of a commit before it go
+// to the boundary commit like in blame.
+func (l *Limits) lastCommitBlame(fl *files.File, repo Repository) (*parsing.BlameOutput, error) {
+	rel, err := fl.Rel(repo.Path)
+	if err != nil {
+		return nil, err
+	}
+	revision := repo.Revision
+	if revision == "" {
+		revision = "HEAD"
+	}
+	out, err := commands.GitLastCommit(repo.Path, rel, revision)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return parsing.ParseUntracked(fl.Path())
+	}
+
+	com, err := lastCommit(out)
+	if err != nil {
+		return nil, err
+	}
+	if repo.Since != "" {
+		boundary, err := commands.GitIsAncestor(repo.Path, com.Hash, repo.Since)
+		if err != nil {
+			return nil, err
+		}
+		if boundary {
+			if out, err = commands.GitLastCommit(repo.Path, "", repo.Since); err != nil {
+				return nil, err
+			}
+			if com, err = lastCommit(out); err != nil {
+				return nil, err
+			}
+			com.Boundary = true
+		}
+	}
+
+	com.LinesNum = l.lines[fl]
+	return &parsing.BlameOutput{
+		Commits: map[string]*parsing.Commit{com.Hash: com},
+	}, nil
+}
+
+// lastCommit parses the output of commands.GitLastCommit.
+func lastCommit(out []byte) (*parsing.Commit, error) {
+	parts := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(parts) != 7 {
+		return nil, commands.ErrorInvalidGitLogOutput{}
+	}
+	return &parsing.Commit{
+		Hash: parts[0],
+		Meta: map[string]string{
+			"author":         parts[1],
+			"author-mail":    "<" + parts[2] + ">",
+			"author-time":    parts[3],
+			"committer":      parts[4],
+			"committer-mail": "<" + parts[5] + ">",
+			"committer-time": parts[6],
+		},
+	}, nil
+}
@@ -0,0 +1,28 @@
+package statistics
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for size, expected := range map[string]int64{
+		"512":  512,
+		"0":    0,
+		"200K": 200 << 10,
+		"200k": 200 << 10,
+		"10M":  10 << 20,
+		"10MB": 10 << 20,
+		"1G":   1 << 30,
+		" 1gb": 1 << 30,
+	} {
+		n, err := ParseSize(size)
+		require.NoError(t, err, size)
+		require.Equal(t, expected, n, size)
+	}
+
+	for _, size := range []string{"", "K", "2X", "-1", "1.5M", "1T"} {
+		_, err := ParseSize(size)
+		require.Error(t, err, size)
+	}
+}
@@ -0,0 +1,63 @@
+package statistics
+
+import (
+	"github.com/20xygen/git-blame/pkg/files"
+	"sort"
+	"strconv"
+)
+
+// LineRecord is a single line of a file attributed to the commit that last changed it.
+type LineRecord struct {
+	Repository string `json:"repository,omitempty"`
+	Path       string `json:"path"`
+	Line       uint64 `json:"line"`
+	Commit     string `json:"commit"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+// CollectLines calls fn for every blamed line, files in lexical order and lines by position.
+func CollectLines(ps *Params, info *files.LangInfo, fn func(*LineRecord) error) error {
+	role := "author"
+	if ps.UseCommitter {
+		role = "committer"
+	}
+
+	return walkRepos(ps, info, func(fl *files.File, rps *Params, repo Repository) error {
+		bo, err := blameFile(fl, rps, repo, true)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filePath(fl, rps)
+		if err != nil {
+			return err
+		}
+
+		lines := bo.Lines
+		sort.Slice(lines, func(i, j int) bool {
+			return lines[i].CurPos < lines[j].CurPos
+		})
+
+		for _, ln := range lines {
+			who := blamedIdentity(ln.Com, role, repo)
+			rec := &LineRecord{
+				Path:   rel,
+				Line:   ln.CurPos,
+				Commit: ln.Com.Hash,
+				Name:   who.Name,
+				Email:  who.Email,
+			}
+			if ps.ShowRepositories {
+				rec.Repository = repo.Name
+			}
+			rec.Timestamp, _ = strconv.ParseInt(ln.Com.Meta[role+"-time"], 10, 64)
+
+			if err = fn(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
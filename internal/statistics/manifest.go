@@ -0,0 +1,59 @@
+package statistics
+
+import (
+	"github.com/20xygen/git-blame/internal/utils"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+)
+
+// Repository is a git repository to collect statistics from.
+type Repository struct {
+	Name     string `yaml:"name"`
+	Path     string `yaml:"path"`
+	Revision string `yaml:"revision"`
+	Since    string `yaml:"since"` // lines before are attributed to the boundary
+}
+
+type manifest struct {
+	Repositories []Repository `yaml:"repositories"`
+}
+
+// ReadManifest loads repositories from a YAML manifest. Relative paths are
+// resolved against the manifest directory, missing revisions and range starts
+// default to revision and since.
+func ReadManifest(path, revision, since string) ([]Repository, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.ErrorConfigFile{
+			E: err,
+		}
+	}
+
+	var mf manifest
+	if err = yaml.UnmarshalStrict(data, &mf); err != nil {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "malformed manifest: " + err.Error(),
+		}
+	}
+
+	for i := range mf.Repositories {
+		repo := &mf.Repositories[i]
+		if repo.Path == "" {
+			return nil, utils.ErrorInvalidParameters{
+				Info: "manifest repository without path",
+			}
+		}
+		if !filepath.IsAbs(repo.Path) {
+			repo.Path = filepath.Join(filepath.Dir(path), repo.Path)
+		}
+		if repo.Revision == "" {
+			repo.Revision = revision
+		}
+		if repo.Since == "" {
+			repo.Since = since
+		}
+	}
+
+	return mf.Repositories, nil
+}
@@ -5,12 +5,14 @@ import (
 	"github.com/20xygen/git-blame/internal/utils"
 	"github.com/20xygen/git-blame/pkg/files"
 	"github.com/spf13/cobra"
+	"path/filepath"
 	"strings"
 )
 
 type Params struct {
-	Path         string
+	Path         string // repository being collected
 	Revision     string
+	Repositories []Repository
 	OrderBy      []string
 	UseCommitter bool
 	Extensions   []string
@@ -18,6 +20,28 @@ type Params struct {
 	Exclude      []string
 	Restrict     []string
 	Format       string
+
+	RecurseSubmodules bool
+	ShowRepositories  bool
+	Worktree          bool // blame the working copy, Revision is empty
+	Teams             *Teams
+	TeamMembers       bool
+	Coauthors         string // one of Coauthors* modes
+	Classify          bool   // classify commits by subjects
+	ClassRules        []ClassRule
+	Count             string // one of Count* kinds of lines to count
+	LineKinds         bool   // report code, comment and blank lines
+	Score             []ScoreTerm
+	AllowShallow      bool
+	Progress          Progress  // set by the caller, nil for none
+	Profile           *Profile  // set by the caller to time blames
+	Limits            *Limits   // nil for no limits on file sizes
+	Baseline          *Baseline // set by the caller to reuse previous results
+}
+
+// countKinds tells whether lines have to be classified by kinds.
+func (ps *Params) countKinds() bool {
+	return ps.Count != CountAll || ps.LineKinds
 }
 
 func (ps *Params) FilterLanguages(info *files.LangInfo) error {
@@ -37,21 +61,67 @@ func (ps *Params) FilterLanguages(info *files.LangInfo) error {
 	return nil
 }
 
+// ResolveRepositories makes repository paths absolute and names unnamed
+// repositories after their directories.
+func (ps *Params) ResolveRepositories() error {
+	names := make(map[string]struct{})
+	for i := range ps.Repositories {
+		repo := &ps.Repositories[i]
+
+		path, err := filepath.Abs(repo.Path)
+		if err != nil {
+			return err
+		}
+		repo.Path = path
+
+		if repo.Name == "" {
+			repo.Name = filepath.Base(path)
+		}
+		if _, ok := names[repo.Name]; ok {
+			return utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("duplicate repository name: %q", repo.Name),
+			}
+		}
+		names[repo.Name] = struct{}{}
+	}
+
+	ps.Path = ps.Repositories[0].Path
+	return nil
+}
+
 func (ps *Params) String() string {
 	var builder strings.Builder
-	_, _ = fmt.Fprintf(&builder, "path\t\t%s\n", ps.Path)
-	_, _ = fmt.Fprintf(&builder, "revision\t%s\n", ps.Revision)
+	for _, repo := range ps.Repositories {
+		_, _ = fmt.Fprintf(&builder, "repository\t%s\t%s\t%s\t%s\n", repo.Name, repo.Path, repo.Since, repo.Revision)
+	}
 	_, _ = fmt.Fprintf(&builder, "orderBy\t\t%s\n", ps.OrderBy)
 	_, _ = fmt.Fprintf(&builder, "useCommitter\t%t\n", ps.UseCommitter)
 	_, _ = fmt.Fprintf(&builder, "extensions\t\t%v\n", ps.Extensions)
 	_, _ = fmt.Fprintf(&builder, "languages\t%v\n", ps.Languages)
 	_, _ = fmt.Fprintf(&builder, "exclude\t\t%v\n", ps.Exclude)
 	_, _ = fmt.Fprintf(&builder, "restrict\t%v\n", ps.Restrict)
+	_, _ = fmt.Fprintf(&builder, "recurseSubmodules\t%t\n", ps.RecurseSubmodules)
+	_, _ = fmt.Fprintf(&builder, "worktree\t%t\n", ps.Worktree)
 	return builder.String()
 }
 
+// parseRange splits a range like "v1.0..main" into its start and end. The end
+// defaults to HEAD like in git.
+func parseRange(revRange string) (string, string, error) {
+	since, revision, ok := strings.Cut(revRange, "..")
+	if !ok || since == "" || strings.HasPrefix(revision, ".") {
+		return "", "", utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("bad range %q, expected A..B", revRange),
+		}
+	}
+	if revision == "" {
+		revision = "HEAD"
+	}
+	return since, revision, nil
+}
+
 func GetParams(cmd cobra.Command) (*Params, error) {
-	path, e1 := cmd.Flags().GetString("repository")
+	paths, e1 := cmd.Flags().GetStringArray("repository")
 	revision, e2 := cmd.Flags().GetString("revision")
 	orderBy, e3 := cmd.Flags().GetStringSlice("order-by")
 	useCommitter, e4 := cmd.Flags().GetBool("use-committer")
@@ -60,16 +130,125 @@ func GetParams(cmd cobra.Command) (*Params, error) {
 	exclude, e7 := cmd.Flags().GetStringSlice("exclude")
 	restrict, e8 := cmd.Flags().GetStringSlice("restrict-to")
 	formatArg, e9 := cmd.Flags().GetString("format")
+	recurseSubmodules, e10 := cmd.Flags().GetBool("recurse-submodules")
+	showRepositories, e11 := cmd.Flags().GetBool("show-repositories")
+	manifestPath, e12 := cmd.Flags().GetString("manifest")
+	worktree, e13 := cmd.Flags().GetBool("worktree")
+	teamsPath, e14 := cmd.Flags().GetString("teams")
+	teamMembers, e15 := cmd.Flags().GetBool("team-members")
+	coauthors, e16 := cmd.Flags().GetString("coauthors")
+	count, e17 := cmd.Flags().GetString("count")
+	lineKinds, e18 := cmd.Flags().GetBool("line-kinds")
+	scoreExpr, e19 := cmd.Flags().GetString("score")
+	revRange, e20 := cmd.Flags().GetString("range")
+	allowShallow, e21 := cmd.Flags().GetBool("allow-shallow")
+	maxFileSize, e22 := cmd.Flags().GetString("max-file-size")
+	maxLines, e23 := cmd.Flags().GetInt("max-lines")
+	oversized, e24 := cmd.Flags().GetString("oversized")
 
-	if utils.AnyError(e1, e2, e3, e4, e5, e6, e7, e8, e9) {
+	if utils.AnyError(e1, e2, e3, e4, e5, e6, e7, e8, e9, e10, e11, e12, e13, e14, e15, e16, e17, e18, e19, e20, e21, e22, e23, e24) {
 		return nil, utils.ErrorInvalidParameters{
 			Info: "unexpected error",
 		}
 	}
 
+	if !utils.Contains([]string{CoauthorsIgnore, CoauthorsSplit, CoauthorsFull}, coauthors) {
+		return nil, utils.ErrorInvalidParameters{
+			Info: fmt.Sprintf("unexpected co-authors mode: %q", coauthors),
+		}
+	}
+
+	if err := validateCount(count); err != nil {
+		return nil, err
+	}
+
+	var score []ScoreTerm
+	if scoreExpr != "" {
+		var err error
+		score, err = ParseScore(scoreExpr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var maxSize int64
+	if maxFileSize != "" {
+		var err error
+		maxSize, err = ParseSize(maxFileSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	limits, err := NewLimits(maxSize, maxLines, oversized)
+	if err != nil {
+		return nil, err
+	}
+	if limits != nil && limits.Action == OversizedLastCommit && (count != CountAll || lineKinds) {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "--oversized last-commit doesn't read lines, it can't be used with --count or --line-kinds",
+		}
+	}
+
+	since := ""
+	if revRange != "" {
+		var err error
+		since, revision, err = parseRange(revRange)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var repos []Repository
+	if manifestPath != "" {
+		var err error
+		if worktree {
+			revision, since = "", ""
+		}
+		repos, err = ReadManifest(manifestPath, revision, since)
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range repos {
+			if worktree && (repo.Revision != "" || repo.Since != "") {
+				return nil, utils.ErrorInvalidParameters{
+					Info: fmt.Sprintf("manifest revision of %s conflicts with --worktree", repo.Path),
+				}
+			}
+		}
+	} else {
+		for _, path := range paths {
+			repos = append(repos, Repository{
+				Path:     path,
+				Revision: revision,
+				Since:    since,
+			})
+		}
+	}
+	if len(repos) == 0 {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "no repositories",
+		}
+	}
+	var teams *Teams
+	if teamsPath != "" {
+		var err error
+		teams, err = ReadTeams(teamsPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if worktree {
+		for i := range repos {
+			repos[i].Revision = ""
+			repos[i].Since = ""
+		}
+	}
+
 	return &Params{
-		Path:         path,
-		Revision:     revision,
+		Path:         repos[0].Path,
+		Revision:     repos[0].Revision,
+		Repositories: repos,
 		OrderBy:      orderBy,
 		UseCommitter: useCommitter,
 		Extensions:   extensions,
@@ -77,5 +256,17 @@ func GetParams(cmd cobra.Command) (*Params, error) {
 		Exclude:      exclude,
 		Restrict:     restrict,
 		Format:       formatArg,
+
+		RecurseSubmodules: recurseSubmodules,
+		ShowRepositories:  showRepositories,
+		Worktree:          worktree,
+		Teams:             teams,
+		TeamMembers:       teamMembers,
+		Coauthors:         coauthors,
+		Count:             count,
+		LineKinds:         lineKinds,
+		Score:             score,
+		AllowShallow:      allowShallow,
+		Limits:            limits,
 	}, nil
 }
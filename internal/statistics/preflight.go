@@ -0,0 +1,80 @@
+package statistics
+
+import (
+	"errors"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/commands"
+	"log/slog"
+	"strings"
+)
+
+// Preflight checks every repository before collection: the path must be a
+// work tree or a bare repository, revisions are resolved to commit hashes and
+// shallow clones are rejected unless allowed. Warnings are returned for the
+// caller to show.
+func (ps *Params) Preflight() ([]string, error) {
+	var warnings []string
+	for i := range ps.Repositories {
+		repo := &ps.Repositories[i]
+
+		out, err := commands.GitRepositoryState(repo.Path)
+		if errors.Is(err, commands.ErrNotRepository) {
+			return nil, commands.ErrorNotRepository{Path: repo.Path}
+		}
+		if err != nil {
+			return nil, err
+		}
+		state := strings.Fields(string(out))
+		if len(state) != 3 {
+			return nil, commands.ErrorInvalidGitRevParseOutput{}
+		}
+		bare, workTree, shallow := state[0] == "true", state[1] == "true", state[2] == "true"
+
+		if !bare && !workTree {
+			return nil, commands.ErrorNotRepository{Path: repo.Path}
+		}
+		if bare && ps.Worktree {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("--worktree needs a working copy, %s is bare", repo.Path),
+			}
+		}
+
+		if shallow {
+			if !ps.AllowShallow {
+				return nil, commands.ErrorShallowRepository{Path: repo.Path}
+			}
+			warnings = append(warnings, repo.Name+" is a shallow clone, lines older than its history are attributed to the oldest fetched commits")
+			slog.Warn("Shallow repository", "repository", repo.Name)
+		}
+
+		if ps.Worktree {
+			continue
+		}
+		repo.Revision, err = resolveCommit(repo.Path, repo.Revision)
+		if err != nil {
+			return nil, err
+		}
+		if repo.Since != "" {
+			repo.Since, err = resolveCommit(repo.Path, repo.Since)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ps.Revision = ps.Repositories[0].Revision
+	return warnings, nil
+}
+
+func resolveCommit(path, revision string) (string, error) {
+	out, err := commands.GitResolveCommit(path, revision)
+	var execErr commands.ErrorCommandExecution
+	if errors.As(err, &execErr) {
+		return "", commands.ErrorBadRevision{Path: path, Revision: revision}
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
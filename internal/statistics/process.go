@@ -2,9 +2,15 @@ package statistics
 
 import (
 	"github.com/20xygen/git-blame/internal/utils"
+	"github.com/20xygen/git-blame/pkg/commands"
 	"github.com/20xygen/git-blame/pkg/files"
 	"github.com/20xygen/git-blame/pkg/parsing"
+	"log/slog"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func getFileFilter(ps *Params, info *files.LangInfo) func(*files.File) (bool, error) {
@@ -66,44 +72,221 @@ func getFileFilter(ps *Params, info *files.LangInfo) func(*files.File) (bool, er
 	}
 }
 
-func processFile(fl *files.File, st *Stat, ps *Params) error {
-	bo, err := parsing.ParseBlame(ps.Path, fl.Path(), ps.Revision)
+// fileFunc is called for every file passing the filter with parameters scoped
+// to the top-level repository the file belongs to.
+type fileFunc func(fl *files.File, ps *Params, repo Repository) error
+
+// blameFile blames the file by its path relative to the repository, as bare
+// repositories reject absolute paths. Lines with contents are kept only if
+// needed, aggregate reports just count them per commit. Blames are timed if
+// profiling.
+func blameFile(fl *files.File, ps *Params, repo Repository, lines bool) (*parsing.BlameOutput, error) {
+	if fl.Untracked {
+		return parsing.ParseUntracked(fl.Path())
+	}
+	if ps.Limits.approximated(fl) {
+		return ps.Limits.lastCommitBlame(fl, repo)
+	}
+	rel, err := fl.Rel(repo.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	blame := parsing.CountBlame
+	if lines {
+		blame = parsing.ParseBlame
+	}
+	bo, err := blame(repo.Path, rel, repo.Since, repo.Revision)
+	if err != nil {
+		return nil, err
+	}
+	if ps.Profile != nil {
+		path, err := filePath(fl, ps)
+		if err != nil {
+			return nil, err
+		}
+		ps.Profile.record(path, time.Since(start), bo)
+	}
+	return bo, nil
+}
+
+// BoundaryName holds lines written before the start of the blamed range.
+const BoundaryName = "Boundary"
+
+// blamedIdentity returns who the commit lines are attributed to in the role.
+func blamedIdentity(com *parsing.Commit, role string, repo Repository) identity {
+	if repo.Since != "" && com.Boundary {
+		return identity{Name: BoundaryName}
+	}
+	return identity{
+		Name:  com.Meta[role],
+		Email: strings.Trim(com.Meta[role+"-mail"], "<>"),
+	}
+}
+
+func commitMeta(com *parsing.Commit, t int64, boundary bool) CommitMeta {
+	authorTime, _ := strconv.ParseInt(com.Meta["author-time"], 10, 64)
+	committerTime, _ := strconv.ParseInt(com.Meta["committer-time"], 10, 64)
+	return CommitMeta{
+		Time:           t,
+		Author:         com.Meta["author"],
+		AuthorEmail:    strings.Trim(com.Meta["author-mail"], "<>"),
+		AuthorTime:     authorTime,
+		Committer:      com.Meta["committer"],
+		CommitterEmail: strings.Trim(com.Meta["committer-mail"], "<>"),
+		CommitterTime:  committerTime,
+		Summary:        com.Meta["summary"],
+		Boundary:       boundary,
+	}
+}
+
+// filePath returns the path of the file relative to its top-level repository,
+// prefixed by the repository name when several repositories are analyzed.
+func filePath(fl *files.File, ps *Params) (string, error) {
+	rel, err := fl.Rel(ps.Path)
+	if err != nil {
+		return "", err
+	}
+	if len(ps.Repositories) > 1 {
+		for _, top := range ps.Repositories {
+			if top.Path == ps.Path {
+				return path.Join(top.Name, rel), nil
+			}
+		}
+	}
+	return rel, nil
+}
+
+func processFile(fl *files.File, st *Stat, ps *Params, info *files.LangInfo, repo Repository, commits commitCache) error {
+	bo, err := blameFile(fl, ps, repo, ps.countKinds())
+	if err != nil {
+		return err
+	}
+
+	var kinds map[string]LineKinds
+	if ps.countKinds() && !ps.Limits.approximated(fl) {
+		syntax, _ := fl.CommentSyntax(info)
+		kinds = commitKinds(bo, syntax)
+	}
+
+	rel, err := filePath(fl, ps)
 	if err != nil {
 		return err
 	}
+	sf := &StatFile{
+		Repository: repo.Name,
+		Users:      make(map[string]int),
+		Times:      make(map[int64]int),
+		Authors:    make(map[string]*FileAuthor),
+	}
+
+	role := "author"
+	if ps.UseCommitter {
+		role = "committer"
+	}
+
 	for _, com := range bo.Commits {
-		var name string
-		if !ps.UseCommitter {
-			name = com.Meta["author"]
-		} else {
-			name = com.Meta["committer"]
+		t, _ := strconv.ParseInt(com.Meta[role+"-time"], 10, 64)
+		author := blamedIdentity(com, role, repo)
+		boundary := repo.Since != "" && com.Boundary
+		st.Commits[com.Hash] = commitMeta(com, t, boundary)
+
+		var cos []identity
+		class := ""
+		if boundary && ps.Classify {
+			class = ClassOther
+		} else if !boundary && (ps.Coauthors != CoauthorsIgnore && !ps.UseCommitter || ps.Classify) {
+			ci, err := commits.get(repo, com.Hash)
+			if err != nil {
+				return err
+			}
+			if ps.Coauthors != CoauthorsIgnore && !ps.UseCommitter {
+				cos = ci.Coauthors
+			}
+			if ps.Classify {
+				class = classify(ci.Subject, ps.ClassRules)
+			}
 		}
 
-		usr, ok := st.Users[name]
-		if !ok {
-			usr = &StatUser{
-				Commits: make(map[string]struct{}),
-				Files:   make(map[string]struct{}),
-				Lines:   0,
+		lines := com.LinesNum
+		crs := credits(lines, author, cos, ps.Coauthors)
+		var crKinds []LineKinds
+		if kinds != nil {
+			// kinds are split along with lines, so they sum up to credited lines
+			lk := kinds[com.Hash]
+			crs = credits(lk.Count(CountAll), author, cos, ps.Coauthors)
+			crKinds = splitKinds(lk, crs, ps.Coauthors)
+			for i := range crs {
+				crs[i].Lines = crKinds[i].Count(ps.Count)
 			}
-			st.Users[name] = usr
+			lines = lk.Count(ps.Count)
 		}
 
-		usr.Commits[com.Hash] = struct{}{}
-		usr.Files[fl.Path()] = struct{}{}
-		usr.Lines += com.LinesNum
+		sf.Lines += lines
+		for i, cr := range crs {
+			// identities without counted lines of the commit get no commit or
+			// file, empty files are still credited to their last commit
+			if cr.Lines == 0 && com.LinesNum > 0 {
+				continue
+			}
+
+			fa, ok := sf.Authors[cr.Name]
+			if !ok {
+				fa = NewFileAuthor()
+				sf.Authors[cr.Name] = fa
+			}
+
+			fa.Commits[com.Hash] += cr.Lines
+			fa.Lines += cr.Lines
+			if cr.Email != "" {
+				fa.Emails[cr.Email] = struct{}{}
+			}
+			sf.Users[cr.Name] += cr.Lines
+			if class != "" {
+				fa.Classes[class] += cr.Lines
+			}
+			if kinds != nil {
+				fa.Kinds.Add(crKinds[i])
+			}
+
+			sf.Times[t] += cr.Lines
+		}
 	}
+
+	st.AddFile(rel, sf)
 	return nil
 }
 
-func CollectStat(ps *Params, info *files.LangInfo) (*Stat, error) {
-	st := &Stat{
-		Users: make(map[string]*StatUser),
+// revisionTime returns the timestamp of the revision, or the current time for the working copy.
+func revisionTime(ps *Params, repo Repository) (int64, error) {
+	if ps.Worktree {
+		return time.Now().Unix(), nil
 	}
 
-	d, err := files.GetDirGit(ps.Path, ps.Revision)
+	out, err := commands.GitCommitTime(repo.Path, repo.Revision)
 	if err != nil {
-		return nil, err
+		return 0, err
+	}
+
+	t, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, commands.ErrorInvalidGitLogOutput{}
+	}
+	return t, nil
+}
+
+func walkRepo(ps *Params, info *files.LangInfo, repo Repository, fn fileFunc) error {
+	var d *files.Dir
+	var subs []files.Submodule
+	var err error
+	if ps.Worktree {
+		d, subs, err = files.GetDirSubmodules(repo.Path)
+	} else {
+		d, subs, err = files.GetDirGitSubmodules(repo.Path, repo.Revision)
+	}
+	if err != nil {
+		return err
 	}
 
 	filter := getFileFilter(ps, info)
@@ -114,10 +297,126 @@ func CollectStat(ps *Params, info *files.LangInfo) (*Stat, error) {
 			return errF
 		}
 		if ok {
-			return processFile(fl, st, ps)
+			return fn(fl, ps, repo)
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if !ps.RecurseSubmodules {
+			slog.Info("Skipping submodule", "repository", repo.Name, "path", sub.Path)
+			continue
+		}
+		if !sub.CheckedOut(repo.Path) {
+			slog.Warn("Skipping submodule that is not checked out", "repository", repo.Name, "path", sub.Path)
+			continue
+		}
+
+		err = walkRepo(ps, info, Repository{
+			Name:     path.Join(repo.Name, sub.Path),
+			Path:     filepath.Join(repo.Path, sub.Path),
+			Revision: sub.Hash,
+		}, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileTask is a file to process with its parameters and repository.
+type fileTask struct {
+	fl   *files.File
+	ps   *Params
+	repo Repository
+}
+
+// walkRepos calls fn for every filtered file of all repositories and their
+// submodules. Files are listed first, so progress knows the total.
+func walkRepos(ps *Params, info *files.LangInfo, fn fileFunc) error {
+	return walkReposSkipping(ps, info, nil, fn)
+}
+
+// walkReposSkipping is walkRepos not calling fn for files skip tells by
+// their paths, nil skips nothing.
+func walkReposSkipping(ps *Params, info *files.LangInfo, skip func(path string) bool, fn fileFunc) error {
+	var tasks []fileTask
+	for _, repo := range ps.Repositories {
+		rps := *ps
+		rps.Path = repo.Path
+		rps.Revision = repo.Revision
+
+		err := walkRepo(&rps, info, repo, func(fl *files.File, ps *Params, repo Repository) error {
+			tasks = append(tasks, fileTask{fl, ps, repo})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	progress := ps.progress()
+	progress.Start(len(tasks))
+	for _, task := range tasks {
+		rel, err := filePath(task.fl, task.ps)
+		if err != nil {
+			return err
+		}
+		progress.File(rel)
+
+		if ps.Limits != nil {
+			oversized, err := ps.Limits.check(task.fl, task.repo, rel)
+			if err != nil {
+				return err
+			}
+			if oversized {
+				slog.Info("Skipping oversized file", "path", rel)
+				continue
+			}
+		}
+		if skip != nil && skip(rel) {
+			continue
+		}
+
+		start := time.Now()
+		if err = fn(task.fl, task.ps, task.repo); err != nil {
+			return err
+		}
+		slog.Debug("Processed file", "path", rel, "duration", time.Since(start))
+	}
+	progress.Finish()
+	return nil
+}
+
+func CollectStat(ps *Params, info *files.LangInfo) (*Stat, error) {
+	st := NewStat()
+
+	for _, repo := range ps.Repositories {
+		t, err := revisionTime(ps, repo)
+		if err != nil {
+			return nil, err
+		}
+		st.Time = max(st.Time, t)
+	}
+
+	commits := make(commitCache)
+	fn := func(fl *files.File, ps *Params, repo Repository) error {
+		return processFile(fl, st, ps, info, repo, commits)
+	}
+	var skip func(path string) bool
+	if ps.Baseline != nil {
+		skip = ps.Baseline.keep
+	}
+	if err := walkReposSkipping(ps, info, skip, fn); err != nil {
+		return nil, err
+	}
+	if ps.Baseline != nil {
+		ps.Baseline.addKept(st)
+	}
 
-	return st, err
+	return st, nil
 }
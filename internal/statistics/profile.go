@@ -0,0 +1,102 @@
+package statistics
+
+import (
+	"github.com/20xygen/git-blame/pkg/parsing"
+	"path"
+	"sort"
+	"time"
+)
+
+// FileTiming is the cost of blaming a single file.
+type FileTiming struct {
+	Path     string
+	Duration time.Duration // wall time of git blame and parsing
+	Size     int           // bytes of the porcelain output
+	Lines    int
+}
+
+// ProfileGroup sums timings of files sharing an extension or a directory.
+type ProfileGroup struct {
+	Name     string
+	Files    int
+	Duration time.Duration
+	Size     int
+}
+
+// Profile records timings of all blamed files.
+type Profile struct {
+	Files []FileTiming
+}
+
+func (p *Profile) record(path string, d time.Duration, bo *parsing.BlameOutput) {
+	p.Files = append(p.Files, FileTiming{
+		Path:     path,
+		Duration: d,
+		Size:     bo.Size,
+		Lines:    bo.LinesNum(),
+	})
+}
+
+// Slowest returns at most n files taking the longest to blame.
+func (p *Profile) Slowest(n int) []FileTiming {
+	files := append([]FileTiming{}, p.Files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Duration > files[j].Duration
+	})
+	return files[:min(n, len(files))]
+}
+
+// Total sums timings of all files.
+func (p *Profile) Total() ProfileGroup {
+	total := ProfileGroup{Name: "total"}
+	for _, ft := range p.Files {
+		total.Files++
+		total.Duration += ft.Duration
+		total.Size += ft.Size
+	}
+	return total
+}
+
+// ByExtension sums timings per file extension, the slowest first.
+func (p *Profile) ByExtension() []ProfileGroup {
+	return p.group(func(ft FileTiming) string {
+		if ext := path.Ext(ft.Path); ext != "" {
+			return ext
+		}
+		return "(none)"
+	})
+}
+
+// ByDirectory sums timings per directory cut at the depth, the slowest first.
+func (p *Profile) ByDirectory(depth int) []ProfileGroup {
+	return p.group(func(ft FileTiming) string {
+		return dirOf(ft.Path, depth)
+	})
+}
+
+func (p *Profile) group(key func(FileTiming) string) []ProfileGroup {
+	groups := make(map[string]*ProfileGroup)
+	for _, ft := range p.Files {
+		name := key(ft)
+		g, ok := groups[name]
+		if !ok {
+			g = &ProfileGroup{Name: name}
+			groups[name] = g
+		}
+		g.Files++
+		g.Duration += ft.Duration
+		g.Size += ft.Size
+	}
+
+	res := make([]ProfileGroup, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, *g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Duration != res[j].Duration {
+			return res[i].Duration > res[j].Duration
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
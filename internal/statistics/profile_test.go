@@ -0,0 +1,43 @@
+package statistics
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestProfile(t *testing.T) {
+	p := &Profile{
+		Files: []FileTiming{
+			{Path: "main.go", Duration: 2 * time.Millisecond, Size: 100, Lines: 10},
+			{Path: "pkg/a/a.go", Duration: 5 * time.Millisecond, Size: 300, Lines: 30},
+			{Path: "pkg/b/b.go", Duration: 1 * time.Millisecond, Size: 50, Lines: 5},
+			{Path: "pkg/README", Duration: 3 * time.Millisecond, Size: 20, Lines: 2},
+		},
+	}
+
+	slowest := p.Slowest(2)
+	require.Len(t, slowest, 2)
+	require.Equal(t, "pkg/a/a.go", slowest[0].Path)
+	require.Equal(t, "pkg/README", slowest[1].Path)
+	require.Len(t, p.Slowest(10), 4)
+
+	require.Equal(t, ProfileGroup{Name: "total", Files: 4, Duration: 11 * time.Millisecond, Size: 470}, p.Total())
+
+	require.Equal(t, []ProfileGroup{
+		{Name: ".go", Files: 3, Duration: 8 * time.Millisecond, Size: 450},
+		{Name: "(none)", Files: 1, Duration: 3 * time.Millisecond, Size: 20},
+	}, p.ByExtension())
+
+	require.Equal(t, []ProfileGroup{
+		{Name: "pkg", Files: 3, Duration: 9 * time.Millisecond, Size: 370},
+		{Name: ".", Files: 1, Duration: 2 * time.Millisecond, Size: 100},
+	}, p.ByDirectory(1))
+
+	require.Equal(t, []ProfileGroup{
+		{Name: "pkg/a", Files: 1, Duration: 5 * time.Millisecond, Size: 300},
+		{Name: "pkg", Files: 1, Duration: 3 * time.Millisecond, Size: 20},
+		{Name: ".", Files: 1, Duration: 2 * time.Millisecond, Size: 100},
+		{Name: "pkg/b", Files: 1, Duration: 1 * time.Millisecond, Size: 50},
+	}, p.ByDirectory(2))
+}
@@ -0,0 +1,21 @@
+package statistics
+
+// Progress is notified about files being processed.
+type Progress interface {
+	Start(total int)
+	File(path string) // called before the file is processed
+	Finish()
+}
+
+type noProgress struct{}
+
+func (noProgress) Start(int)   {}
+func (noProgress) File(string) {}
+func (noProgress) Finish()     {}
+
+func (ps *Params) progress() Progress {
+	if ps.Progress == nil {
+		return noProgress{}
+	}
+	return ps.Progress
+}
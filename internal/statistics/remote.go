@@ -0,0 +1,79 @@
+package statistics
+
+import (
+	"github.com/20xygen/git-blame/pkg/commands"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// isRemote tells bundle files and file:// URLs, which are cloned before collection.
+func isRemote(path string) bool {
+	if strings.HasPrefix(path, "file://") {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// remoteName names a cloned repository after its source, e.g. "api" for "/mirrors/api.bundle".
+func remoteName(source string) string {
+	name := filepath.Base(strings.TrimRight(strings.TrimPrefix(source, "file://"), "/"))
+	for _, ext := range []string{".bundle", ".git"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// CloneRemotes clones bundles and file:// URLs into temporary bare
+// repositories used instead of them. The returned function removes the clones,
+// it is never nil, clones are already removed on errors.
+func (ps *Params) CloneRemotes() (func(), error) {
+	var dirs []string
+	cleanup := func() {
+		for _, dir := range dirs {
+			if err := os.RemoveAll(dir); err != nil {
+				slog.Warn("Failed to remove clone", "path", dir, "error", err)
+			}
+		}
+	}
+
+	for i := range ps.Repositories {
+		repo := &ps.Repositories[i]
+		if !isRemote(repo.Path) {
+			continue
+		}
+
+		source := repo.Path
+		if !strings.HasPrefix(source, "file://") {
+			abs, err := filepath.Abs(source)
+			if err != nil {
+				cleanup()
+				return func() {}, err
+			}
+			source = abs
+		}
+
+		dir, err := os.MkdirTemp("", "blame-")
+		if err != nil {
+			cleanup()
+			return func() {}, err
+		}
+		dirs = append(dirs, dir)
+
+		dest := filepath.Join(dir, "repo.git")
+		slog.Info("Cloning repository", "source", source, "path", dest)
+		if _, err = commands.GitClone(source, dest); err != nil {
+			cleanup()
+			return func() {}, err
+		}
+
+		if repo.Name == "" {
+			repo.Name = remoteName(source)
+		}
+		repo.Path = dest
+	}
+
+	return cleanup, nil
+}
@@ -0,0 +1,176 @@
+package statistics
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metrics available in score expressions, all within [0, 1].
+const (
+	MetricLinesShare   = "lines_share"
+	MetricCommitsShare = "commits_share"
+	MetricFilesShare   = "files_share"
+	MetricRecency      = "recency"
+)
+
+// recencyHalfLife is the age in seconds at which a line weighs half in recency.
+const recencyHalfLife = 365 * 24 * 60 * 60
+
+// scorePresets are named weightings accepted instead of expressions.
+var scorePresets = map[string]string{
+	"balanced": "0.6*lines_share + 0.3*files_share + 0.1*recency",
+	"activity": "0.4*commits_share + 0.3*files_share + 0.3*recency",
+	"lines":    "lines_share",
+}
+
+// ScoreTerm is a weighted metric of a score expression.
+type ScoreTerm struct {
+	Weight float64
+	Metric string
+}
+
+// ParseScore parses a preset name or a sum of terms like "0.6*lines_share".
+// A term without a weight has the weight of 1.
+func ParseScore(expr string) ([]ScoreTerm, error) {
+	if preset, ok := scorePresets[strings.TrimSpace(expr)]; ok {
+		expr = preset
+	}
+
+	metrics := []string{MetricLinesShare, MetricCommitsShare, MetricFilesShare, MetricRecency}
+	var terms []ScoreTerm
+	for _, part := range strings.Split(expr, "+") {
+		term := ScoreTerm{Weight: 1}
+		for _, factor := range strings.Split(part, "*") {
+			factor = strings.TrimSpace(factor)
+			if utils.Contains(metrics, factor) && term.Metric == "" {
+				term.Metric = factor
+				continue
+			}
+			w, err := strconv.ParseFloat(factor, 64)
+			if err != nil {
+				return nil, utils.ErrorInvalidParameters{
+					Info: fmt.Sprintf("bad score term %q, expected weight*metric with metric one of %s or preset one of %s",
+						strings.TrimSpace(part), strings.Join(metrics, ", "), strings.Join(presetNames(), ", ")),
+				}
+			}
+			term.Weight *= w
+		}
+		if term.Metric == "" {
+			return nil, utils.ErrorInvalidParameters{
+				Info: fmt.Sprintf("no metric in score term %q", strings.TrimSpace(part)),
+			}
+		}
+		terms = append(terms, term)
+	}
+	return terms, nil
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(scorePresets))
+	for name := range scorePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Totals are sums over all users, users are measured against them.
+type Totals struct {
+	Lines   int
+	Commits int // distinct commits
+	Files   int
+	Time    int64 // timestamp of the latest analyzed revision
+}
+
+func (st *Stat) Totals() Totals {
+	commits := make(map[string]struct{})
+	t := Totals{
+		Files: len(st.Files),
+		Time:  st.Time,
+	}
+	for _, usr := range st.Users {
+		t.Lines += usr.Lines
+		for hash := range usr.Commits {
+			commits[hash] = struct{}{}
+		}
+	}
+	t.Commits = len(commits)
+	return t
+}
+
+func share(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+func (t Totals) LinesShare(usr *StatUser) float64 {
+	return share(usr.Lines, t.Lines)
+}
+
+func (t Totals) CommitsShare(usr *StatUser) float64 {
+	return share(len(usr.Commits), t.Commits)
+}
+
+func (t Totals) FilesShare(usr *StatUser) float64 {
+	return share(len(usr.Files), t.Files)
+}
+
+// Recency is the mean weight of the user lines, halving every year of age.
+func (t Totals) Recency(usr *StatUser) float64 {
+	var sum float64
+	lines := 0
+	for stamp, n := range usr.Times {
+		age := max(t.Time-stamp, 0)
+		sum += float64(n) * math.Exp2(-float64(age)/recencyHalfLife)
+		lines += n
+	}
+	if lines == 0 {
+		return 0
+	}
+	return sum / float64(lines)
+}
+
+// MedianAge is the median age of the user lines in days.
+func (t Totals) MedianAge(usr *StatUser) float64 {
+	ages := ComputeAges(usr.Times, t.Time, []AgeBucket{{}})
+	return ages.Median.Hours() / 24
+}
+
+// Scorer scores users against totals of the whole statistics, so team members
+// and teams are comparable.
+type Scorer struct {
+	terms  []ScoreTerm
+	totals Totals
+}
+
+func NewScorer(terms []ScoreTerm, totals Totals) *Scorer {
+	return &Scorer{
+		terms:  terms,
+		totals: totals,
+	}
+}
+
+func (sc *Scorer) Score(usr *StatUser) float64 {
+	var score float64
+	for _, term := range sc.terms {
+		var v float64
+		switch term.Metric {
+		case MetricLinesShare:
+			v = sc.totals.LinesShare(usr)
+		case MetricCommitsShare:
+			v = sc.totals.CommitsShare(usr)
+		case MetricFilesShare:
+			v = sc.totals.FilesShare(usr)
+		case MetricRecency:
+			v = sc.totals.Recency(usr)
+		}
+		score += term.Weight * v
+	}
+	return math.Round(score*1e4) / 1e4
+}
@@ -0,0 +1,305 @@
+package statistics
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SnapshotVersion is the version of the snapshot format written, it changes
+// whenever snapshots of the previous version can't be read as is.
+const SnapshotVersion = 1
+
+// ErrorSnapshotVersion is a snapshot of another format version.
+type ErrorSnapshotVersion struct {
+	Version int
+}
+
+func (e ErrorSnapshotVersion) Error() string { return e.Unwrap().Error() }
+
+func (e ErrorSnapshotVersion) Unwrap() error {
+	return utils.ErrorInvalidParameters{
+		Info: fmt.Sprintf("unsupported snapshot version %d, expected %d", e.Version, SnapshotVersion),
+	}
+}
+
+// SnapshotParams are parameters statistics of files depend on. A snapshot
+// is only reused as a baseline with the same parameters.
+type SnapshotParams struct {
+	UseCommitter bool     `json:"use_committer"`
+	Extensions   []string `json:"extensions,omitempty"`
+	Languages    []string `json:"languages,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	Restrict     []string `json:"restrict_to,omitempty"`
+	Coauthors    string   `json:"coauthors"`
+	Classify     bool     `json:"classify"`
+	ClassRules   []string `json:"class_rules,omitempty"` // "name=regex"
+	Count        string   `json:"count"`
+	LineKinds    bool     `json:"line_kinds"`
+	MaxFileSize  int64    `json:"max_file_size,omitempty"`
+	MaxLines     int      `json:"max_lines,omitempty"`
+	Oversized    string   `json:"oversized,omitempty"`
+}
+
+func snapshotParams(ps *Params) SnapshotParams {
+	sp := SnapshotParams{
+		UseCommitter: ps.UseCommitter,
+		Extensions:   ps.Extensions,
+		Languages:    ps.Languages,
+		Exclude:      ps.Exclude,
+		Restrict:     ps.Restrict,
+		Coauthors:    ps.Coauthors,
+		Classify:     ps.Classify,
+		Count:        ps.Count,
+		LineKinds:    ps.LineKinds,
+	}
+	for _, rule := range ps.ClassRules {
+		sp.ClassRules = append(sp.ClassRules, rule.Name+"="+rule.Re.String())
+	}
+	if ps.Limits != nil {
+		sp.MaxFileSize = ps.Limits.MaxSize
+		sp.MaxLines = ps.Limits.MaxLines
+		sp.Oversized = ps.Limits.Action
+	}
+	return sp
+}
+
+// equal compares parameters by their encoding, so nil and empty lists match.
+func (o SnapshotParams) equal(other SnapshotParams) bool {
+	a, _ := json.Marshal(o)
+	b, _ := json.Marshal(other)
+	return bytes.Equal(a, b)
+}
+
+// SnapshotRepository is a repository with the commit its files were blamed at.
+type SnapshotRepository struct {
+	Name     string `json:"name"`
+	Revision string `json:"revision"`
+	Since    string `json:"since,omitempty"`
+}
+
+// SnapshotAuthor is what an author contributes to a file.
+type SnapshotAuthor struct {
+	Lines   int            `json:"lines"`
+	Commits map[string]int `json:"commits"` // lines per commit hash
+	Emails  []string       `json:"emails,omitempty"`
+	Classes map[string]int `json:"classes,omitempty"`
+	Kinds   *LineKinds     `json:"kinds,omitempty"`
+}
+
+type SnapshotFile struct {
+	Repository string                     `json:"repository"`
+	Lines      int                        `json:"lines"`
+	Authors    map[string]*SnapshotAuthor `json:"authors"`
+}
+
+// Snapshot keeps collected statistics per file and author with the blamed
+// commits, so reports are rendered again without git and later runs only
+// blame files changed since. The format is described in the README.
+type Snapshot struct {
+	Version      int                      `json:"version"`
+	Params       SnapshotParams           `json:"params"`
+	Repositories []SnapshotRepository     `json:"repositories"`
+	Time         int64                    `json:"time"` // of the latest revision
+	Commits      map[string]CommitMeta    `json:"commits"`
+	Files        map[string]*SnapshotFile `json:"files"`
+}
+
+func NewSnapshot(st *Stat, ps *Params) *Snapshot {
+	s := &Snapshot{
+		Version: SnapshotVersion,
+		Params:  snapshotParams(ps),
+		Time:    st.Time,
+		Commits: st.Commits,
+		Files:   make(map[string]*SnapshotFile, len(st.Files)),
+	}
+	for _, repo := range ps.Repositories {
+		s.Repositories = append(s.Repositories, SnapshotRepository{
+			Name:     repo.Name,
+			Revision: repo.Revision,
+			Since:    repo.Since,
+		})
+	}
+
+	for path, sf := range st.Files {
+		file := &SnapshotFile{
+			Repository: sf.Repository,
+			Lines:      sf.Lines,
+			Authors:    make(map[string]*SnapshotAuthor, len(sf.Authors)),
+		}
+		for name, fa := range sf.Authors {
+			author := &SnapshotAuthor{
+				Lines:   fa.Lines,
+				Commits: fa.Commits,
+				Classes: fa.Classes,
+			}
+			for email := range fa.Emails {
+				author.Emails = append(author.Emails, email)
+			}
+			sort.Strings(author.Emails)
+			if ps.countKinds() {
+				kinds := fa.Kinds
+				author.Kinds = &kinds
+			}
+			file.Authors[name] = author
+		}
+		s.Files[path] = file
+	}
+	return s
+}
+
+// statFile restores statistics of the file, its commits are added to st.
+func (s *Snapshot) statFile(file *SnapshotFile, st *Stat) *StatFile {
+	sf := &StatFile{
+		Repository: file.Repository,
+		Lines:      file.Lines,
+		Users:      make(map[string]int),
+		Times:      make(map[int64]int),
+		Authors:    make(map[string]*FileAuthor, len(file.Authors)),
+	}
+	for name, author := range file.Authors {
+		fa := NewFileAuthor()
+		fa.Lines = author.Lines
+		for hash, n := range author.Commits {
+			st.Commits[hash] = s.Commits[hash]
+			fa.Commits[hash] = n
+			if n > 0 {
+				sf.Times[s.Commits[hash].Time] += n
+			}
+		}
+		for _, email := range author.Emails {
+			fa.Emails[email] = struct{}{}
+		}
+		for class, n := range author.Classes {
+			fa.Classes[class] = n
+		}
+		if author.Kinds != nil {
+			fa.Kinds = *author.Kinds
+		}
+		sf.Authors[name] = fa
+		sf.Users[name] = author.Lines
+	}
+	return sf
+}
+
+// Stat restores statistics of all files.
+func (s *Snapshot) Stat() *Stat {
+	st := NewStat()
+	st.Time = s.Time
+	for path, file := range s.Files {
+		st.AddFile(path, s.statFile(file, st))
+	}
+	return st
+}
+
+// Restore sets parameters the statistics were collected with, reports are
+// rendered with the rest of ps. Collection parameters are not given by users
+// when rendering, so nothing they asked for is overwritten.
+func (s *Snapshot) Restore(ps *Params) error {
+	if ps.LineKinds && !s.Params.LineKinds && s.Params.Count == CountAll {
+		return utils.ErrorInvalidParameters{
+			Info: "snapshot has no kinds of lines, collect it with --line-kinds",
+		}
+	}
+
+	ps.UseCommitter = s.Params.UseCommitter
+	ps.Extensions = s.Params.Extensions
+	ps.Languages = s.Params.Languages
+	ps.Exclude = s.Params.Exclude
+	ps.Restrict = s.Params.Restrict
+	ps.Coauthors = s.Params.Coauthors
+	ps.Classify = s.Params.Classify
+	ps.Count = s.Params.Count
+
+	ps.Repositories = nil
+	for _, repo := range s.Repositories {
+		ps.Repositories = append(ps.Repositories, Repository{
+			Name:     repo.Name,
+			Revision: repo.Revision,
+			Since:    repo.Since,
+		})
+	}
+	if len(ps.Repositories) > 0 {
+		ps.Path = ""
+		ps.Revision = ps.Repositories[0].Revision
+	}
+	return nil
+}
+
+// ReadSnapshot loads the snapshot, gzipped snapshots are recognized by
+// contents.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, utils.ErrorConfigFile{
+			E: err,
+		}
+	}
+	defer func() { _ = f.Close() }()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, utils.ErrorInvalidParameters{
+				Info: "malformed snapshot: " + err.Error(),
+			}
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	var s Snapshot
+	if err = json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "malformed snapshot: " + err.Error(),
+		}
+	}
+	if s.Version != SnapshotVersion {
+		return nil, ErrorSnapshotVersion{Version: s.Version}
+	}
+	if s.Commits == nil {
+		s.Commits = make(map[string]CommitMeta)
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]*SnapshotFile)
+	}
+	return &s, nil
+}
+
+// Write saves the snapshot, gzipped if the path ends with ".gz". The file is
+// replaced only once the snapshot is complete.
+func (s *Snapshot) Write(path string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return utils.ErrorOutput{E: err}
+	}
+	if strings.HasSuffix(path, ".gz") {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err = gz.Write(data); err != nil {
+			return utils.ErrorOutput{E: err}
+		}
+		if err = gz.Close(); err != nil {
+			return utils.ErrorOutput{E: err}
+		}
+		data = buf.Bytes()
+	}
+
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0644); err != nil {
+		return utils.ErrorOutput{E: err}
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return utils.ErrorOutput{E: err}
+	}
+	return nil
+}
@@ -9,6 +9,72 @@ type StatUser struct {
 	Commits map[string]struct{}
 	Files   map[string]struct{}
 	Lines   int
+	Repos   map[string]int      // lines per repository
+	Times   map[int64]int       // lines per commit timestamp
+	Emails  map[string]struct{} // emails the user committed with
+	Classes map[string]int      // lines per commit class, only when classifying
+	Kinds   LineKinds           // only when counting kinds of lines
+}
+
+func NewStatUser() *StatUser {
+	return &StatUser{
+		Commits: make(map[string]struct{}),
+		Files:   make(map[string]struct{}),
+		Repos:   make(map[string]int),
+		Times:   make(map[int64]int),
+		Emails:  make(map[string]struct{}),
+		Classes: make(map[string]int),
+	}
+}
+
+// Merge adds statistics of another user, e.g. a member of the same team.
+func (su *StatUser) Merge(other *StatUser) {
+	for hash := range other.Commits {
+		su.Commits[hash] = struct{}{}
+	}
+	for path := range other.Files {
+		su.Files[path] = struct{}{}
+	}
+	su.Lines += other.Lines
+	for repo, n := range other.Repos {
+		su.Repos[repo] += n
+	}
+	for t, n := range other.Times {
+		su.Times[t] += n
+	}
+	for email := range other.Emails {
+		su.Emails[email] = struct{}{}
+	}
+	for class, n := range other.Classes {
+		su.Classes[class] += n
+	}
+	su.Kinds.Add(other.Kinds)
+}
+
+// FileAuthor is what a single author contributes to a file.
+type FileAuthor struct {
+	Lines   int
+	Commits map[string]int // lines per commit hash
+	Emails  map[string]struct{}
+	Classes map[string]int // lines per commit class, only when classifying
+	Kinds   LineKinds      // only when counting kinds of lines
+}
+
+func NewFileAuthor() *FileAuthor {
+	return &FileAuthor{
+		Commits: make(map[string]int),
+		Emails:  make(map[string]struct{}),
+		Classes: make(map[string]int),
+	}
+}
+
+// StatFile is the statistics of a single file.
+type StatFile struct {
+	Repository string
+	Lines      int
+	Users      map[string]int // lines per user
+	Times      map[int64]int  // lines per commit timestamp
+	Authors    map[string]*FileAuthor
 }
 
 type StatVals struct {
@@ -17,8 +83,63 @@ type StatVals struct {
 	Lines   int `json:"lines"`
 }
 
+// CommitMeta describes a blamed commit. Time is the author or committer time
+// lines of the commit are dated by.
+type CommitMeta struct {
+	Time           int64  `json:"time"`
+	Author         string `json:"author"`
+	AuthorEmail    string `json:"author_email"`
+	AuthorTime     int64  `json:"author_time"`
+	Committer      string `json:"committer"`
+	CommitterEmail string `json:"committer_email"`
+	CommitterTime  int64  `json:"committer_time"`
+	Summary        string `json:"summary,omitempty"`
+	Boundary       bool   `json:"boundary,omitempty"` // older than the blamed range
+}
+
 type Stat struct {
-	Users map[string]*StatUser
+	Users   map[string]*StatUser
+	Files   map[string]*StatFile  // by path relative to the repository
+	Commits map[string]CommitMeta // blamed commits by hash
+	Time    int64                 // timestamp of the latest analyzed revision
+}
+
+func NewStat() *Stat {
+	return &Stat{
+		Users:   make(map[string]*StatUser),
+		Files:   make(map[string]*StatFile),
+		Commits: make(map[string]CommitMeta),
+	}
+}
+
+// AddFile adds the file to statistics of its authors. Commits of the file
+// must be known.
+func (st *Stat) AddFile(path string, sf *StatFile) {
+	st.Files[path] = sf
+	for name, fa := range sf.Authors {
+		usr, ok := st.Users[name]
+		if !ok {
+			usr = NewStatUser()
+			st.Users[name] = usr
+		}
+
+		usr.Files[path] = struct{}{}
+		usr.Lines += fa.Lines
+		usr.Repos[sf.Repository] += fa.Lines
+		for hash, n := range fa.Commits {
+			usr.Commits[hash] = struct{}{}
+			if n > 0 {
+				usr.Times[st.Commits[hash].Time] += n
+			}
+		}
+		for email := range fa.Emails {
+			usr.Emails[email] = struct{}{}
+		}
+		for class, n := range fa.Classes {
+			usr.Classes[class] += n
+		}
+		usr.Kinds.Add(fa.Kinds)
+	}
 }
 
 func (su *StatUser) String() string {
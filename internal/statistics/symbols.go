@@ -0,0 +1,172 @@
+package statistics
+
+import (
+	"github.com/20xygen/git-blame/pkg/files"
+	"github.com/20xygen/git-blame/pkg/parsing"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// SymbolStat is the ownership of a top-level declaration of a Go file.
+type SymbolStat struct {
+	Path  string
+	Name  string // "F", "(*T).M" or "T"
+	Kind  string // "func", "method" or "type"
+	Start int
+	End   int
+	Users map[string]int // lines per user
+}
+
+// Primary returns the author owning most of the lines and their share.
+func (ss *SymbolStat) Primary() (string, float64) {
+	own := ComputeOwnership(ss.Users, 1)
+	return own.Top, own.TopShare
+}
+
+func (ss *SymbolStat) Lines() int {
+	n := 0
+	for _, lines := range ss.Users {
+		n += lines
+	}
+	return n
+}
+
+// symbol is a declaration with its line range, doc comment included.
+type symbol struct {
+	name, kind string
+	start, end int
+}
+
+func recvString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return "*" + recvString(e.X)
+	case *ast.IndexExpr:
+		return recvString(e.X)
+	case *ast.IndexListExpr:
+		return recvString(e.X)
+	case *ast.Ident:
+		return e.Name
+	default:
+		return "?"
+	}
+}
+
+func declRange(fset *token.FileSet, doc *ast.CommentGroup, node ast.Node) (int, int) {
+	start := node.Pos()
+	if doc != nil {
+		start = doc.Pos()
+	}
+	return fset.Position(start).Line, fset.Position(node.End()).Line
+}
+
+func goSymbols(path string, src []byte) ([]symbol, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var syms []symbol
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := symbol{name: d.Name.Name, kind: "func"}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.name = "(" + recvString(d.Recv.List[0].Type) + ")." + d.Name.Name
+				sym.kind = "method"
+			}
+			sym.start, sym.end = declRange(fset, d.Doc, d)
+			syms = append(syms, sym)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				sym := symbol{name: ts.Name.Name, kind: "type"}
+				if d.Lparen.IsValid() {
+					sym.start, sym.end = declRange(fset, ts.Doc, ts)
+				} else {
+					sym.start, sym.end = declRange(fset, d.Doc, d)
+				}
+				syms = append(syms, sym)
+			}
+		}
+	}
+	return syms, nil
+}
+
+// CollectSymbols reports ownership of top-level functions, methods and types of Go files.
+// Files that fail to parse are skipped.
+func CollectSymbols(ps *Params, info *files.LangInfo) ([]*SymbolStat, error) {
+	role := "author"
+	if ps.UseCommitter {
+		role = "committer"
+	}
+
+	var stats []*SymbolStat
+	err := walkRepos(ps, info, func(fl *files.File, rps *Params, repo Repository) error {
+		if fl.Extension() != ".go" {
+			return nil
+		}
+
+		bo, err := blameFile(fl, rps, repo, true)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filePath(fl, rps)
+		if err != nil {
+			return err
+		}
+
+		lines := bo.Lines
+		sort.Slice(lines, func(i, j int) bool {
+			return lines[i].CurPos < lines[j].CurPos
+		})
+		contents := make([]string, 0, len(lines))
+		for _, ln := range lines {
+			contents = append(contents, ln.Content)
+		}
+
+		syms, err := goSymbols(rel, []byte(strings.Join(contents, "\n")))
+		if err != nil {
+			slog.Warn("Skipping unparsable Go file", "path", rel, "error", err)
+			return nil
+		}
+
+		for _, sym := range syms {
+			ss := &SymbolStat{
+				Path:  rel,
+				Name:  sym.name,
+				Kind:  sym.kind,
+				Start: sym.start,
+				End:   sym.end,
+				Users: make(map[string]int),
+			}
+			for _, ln := range linesInRange(lines, sym.start, sym.end) {
+				ss.Users[blamedIdentity(ln.Com, role, repo).Name]++
+			}
+			stats = append(stats, ss)
+		}
+		return nil
+	})
+
+	return stats, err
+}
+
+// linesInRange returns lines sorted by position within [start, end].
+func linesInRange(lines []*parsing.Line, start, end int) []*parsing.Line {
+	from := sort.Search(len(lines), func(i int) bool {
+		return lines[i].CurPos >= uint64(start)
+	})
+	to := sort.Search(len(lines), func(i int) bool {
+		return lines[i].CurPos > uint64(end)
+	})
+	return lines[from:to]
+}
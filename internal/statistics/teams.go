@@ -0,0 +1,136 @@
+package statistics
+
+import (
+	"fmt"
+	"github.com/20xygen/git-blame/internal/utils"
+	"gopkg.in/yaml.v2"
+	"os"
+	"regexp"
+)
+
+const defaultUnmapped = "Unassigned"
+
+// TeamMember matches identities by exact name, exact email or a regular
+// expression applied to both.
+type TeamMember struct {
+	Name  string `yaml:"name"`
+	Email string `yaml:"email"`
+	Regex string `yaml:"regex"`
+
+	re *regexp.Regexp
+}
+
+type Team struct {
+	Name    string       `yaml:"name"`
+	Members []TeamMember `yaml:"members"`
+}
+
+// Teams maps identities to teams, the first matching team wins.
+type Teams struct {
+	Unmapped string `yaml:"unmapped"`
+	Teams    []Team `yaml:"teams"`
+}
+
+func ReadTeams(path string) (*Teams, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.ErrorConfigFile{
+			E: err,
+		}
+	}
+
+	var ts Teams
+	if err = yaml.UnmarshalStrict(data, &ts); err != nil {
+		return nil, utils.ErrorInvalidParameters{
+			Info: "malformed teams file: " + err.Error(),
+		}
+	}
+
+	if ts.Unmapped == "" {
+		ts.Unmapped = defaultUnmapped
+	}
+	for i := range ts.Teams {
+		team := &ts.Teams[i]
+		for j := range team.Members {
+			m := &team.Members[j]
+			if m.Regex == "" {
+				continue
+			}
+			m.re, err = regexp.Compile(m.Regex)
+			if err != nil {
+				return nil, utils.ErrorInvalidParameters{
+					Info: fmt.Sprintf("bad regex of team %q: %v", team.Name, err),
+				}
+			}
+		}
+	}
+
+	return &ts, nil
+}
+
+func (m *TeamMember) matches(name string, emails map[string]struct{}) bool {
+	if m.Name != "" && m.Name == name {
+		return true
+	}
+	if m.Email != "" {
+		if _, ok := emails[m.Email]; ok {
+			return true
+		}
+	}
+	if m.re != nil {
+		if m.re.MatchString(name) {
+			return true
+		}
+		for email := range emails {
+			if m.re.MatchString(email) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TeamOf returns the team of the user or the unmapped bucket.
+func (ts *Teams) TeamOf(name string, usr *StatUser) string {
+	for i := range ts.Teams {
+		team := &ts.Teams[i]
+		for j := range team.Members {
+			if team.Members[j].matches(name, usr.Emails) {
+				return team.Name
+			}
+		}
+	}
+	return ts.Unmapped
+}
+
+// GroupByTeams merges users into their teams. Members of every team are
+// returned as separate statistics.
+func (st *Stat) GroupByTeams(ts *Teams) (*Stat, map[string]*Stat) {
+	teams := &Stat{
+		Users:   make(map[string]*StatUser),
+		Files:   st.Files,
+		Commits: st.Commits,
+		Time:    st.Time,
+	}
+	members := make(map[string]*Stat)
+
+	for name, usr := range st.Users {
+		team := ts.TeamOf(name, usr)
+
+		tu, ok := teams.Users[team]
+		if !ok {
+			tu = NewStatUser()
+			teams.Users[team] = tu
+			members[team] = &Stat{
+				Users:   make(map[string]*StatUser),
+				Files:   st.Files,
+				Commits: st.Commits,
+				Time:    st.Time,
+			}
+		}
+		tu.Merge(usr)
+		members[team].Users[name] = usr
+	}
+
+	return teams, members
+}
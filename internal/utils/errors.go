@@ -2,12 +2,19 @@ package utils
 
 import "fmt"
 
+// Exit codes by failure class, documented in README.
 const (
 	_ = iota
 	CodeParametersParsing
 	CodeAbsolutePath
 	CodeLanguageInfo
 	CodeFormat
+	CodeNotRepository
+	CodeBadRevision
+	CodeGit
+	CodeParse
+	CodeOutput
+	CodeShallow
 )
 
 type ErrorUndefinedLanguage struct{}
@@ -43,6 +50,8 @@ func (e ErrorConfigFile) Error() string {
 	return fmt.Sprintf("config file not found (%v)", e.E)
 }
 
+func (e ErrorConfigFile) Unwrap() error { return e.E }
+
 type ErrorInvalidPattern struct {
 	E error
 }
@@ -50,3 +59,15 @@ type ErrorInvalidPattern struct {
 func (e ErrorInvalidPattern) Error() string {
 	return fmt.Sprintf("invalid glob pattern (%v)", e.E)
 }
+
+func (e ErrorInvalidPattern) Unwrap() error { return e.E }
+
+type ErrorOutput struct {
+	E error
+}
+
+func (e ErrorOutput) Error() string {
+	return fmt.Sprintf("writing output failed (%v)", e.E)
+}
+
+func (e ErrorOutput) Unwrap() error { return e.E }
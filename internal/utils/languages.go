@@ -25,8 +25,9 @@ func GetLangInfo() (*files.LangInfo, error) {
 	}
 
 	var languages []struct {
-		Name       string   `json:"name"`
-		Extensions []string `json:"extensions"`
+		Name       string               `json:"name"`
+		Extensions []string             `json:"extensions"`
+		Comments   *files.CommentSyntax `json:"comments"`
 	}
 
 	err = json.Unmarshal(data, &languages)
@@ -37,6 +38,7 @@ func GetLangInfo() (*files.LangInfo, error) {
 	info := &files.LangInfo{
 		ExtToLang: make(map[string]string),
 		LangToExs: make(map[string][]string),
+		Comments:  make(map[string]files.CommentSyntax),
 	}
 
 	for _, lang := range languages {
@@ -44,6 +46,9 @@ func GetLangInfo() (*files.LangInfo, error) {
 		for _, ext := range lang.Extensions {
 			info.ExtToLang[ext] = strings.ToLower(lang.Name)
 		}
+		if lang.Comments != nil {
+			info.Comments[strings.ToLower(lang.Name)] = *lang.Comments
+		}
 	}
 
 	return info, nil
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
@@ -16,6 +19,9 @@ const (
 	maxAgeDays = 30
 )
 
+// DefaultLogFile is the rotated log used unless another file is given.
+var DefaultLogFile = filepath.Join(logDir, logFile)
+
 type silentWriter struct{}
 
 func (w silentWriter) Write(p []byte) (n int, err error) {
@@ -24,14 +30,37 @@ func (w silentWriter) Write(p []byte) (n int, err error) {
 
 var defaultWriter = silentWriter{}
 
-func SetupLogger() *slog.Logger {
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		return slog.New(slog.NewTextHandler(defaultWriter, nil))
+// dropDefaultLog warns that the default log can't be written, logs are dropped.
+func dropDefaultLog(err error) io.Writer {
+	_, _ = fmt.Fprintf(os.Stderr, "warning: logs are dropped, %s is not writable (%v), pass --log-file\n", DefaultLogFile, err)
+	return defaultWriter
+}
+
+// LogOptions configure the logger. File "-" means stderr.
+type LogOptions struct {
+	Level  string // one of 'debug', 'info', 'warn', 'error'
+	File   string
+	Format string // one of 'text', 'json'
+}
+
+// logWriter opens the log file with rotation. The default log is dropped with
+// a warning if it can't be written, explicitly given files must be writable.
+func logWriter(path string) (io.Writer, error) {
+	if path == "-" {
+		return os.Stderr, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		if path == DefaultLogFile {
+			return dropDefaultLog(err), nil
+		}
+		return nil, ErrorInvalidParameters{
+			Info: fmt.Sprintf("log file %s: %v", path, err),
+		}
 	}
 
-	logPath := filepath.Join(logDir, logFile)
 	logRotator := &lumberjack.Logger{
-		Filename:   logPath,
+		Filename:   path,
 		MaxSize:    maxSizeMB,
 		MaxBackups: maxBackups,
 		MaxAge:     maxAgeDays,
@@ -39,10 +68,39 @@ func SetupLogger() *slog.Logger {
 	}
 
 	if _, err := logRotator.Write([]byte("")); err != nil {
-		return slog.New(slog.NewTextHandler(defaultWriter, nil))
+		if path == DefaultLogFile {
+			return dropDefaultLog(err), nil
+		}
+		return nil, ErrorInvalidParameters{
+			Info: fmt.Sprintf("log file %s: %v", path, err),
+		}
 	}
+	return logRotator, nil
+}
 
-	return slog.New(slog.NewJSONHandler(logRotator, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+func SetupLogger(opts LogOptions) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		return nil, ErrorInvalidParameters{
+			Info: fmt.Sprintf("unexpected log level: %q", opts.Level),
+		}
+	}
+
+	w, err := logWriter(opts.File)
+	if err != nil {
+		return nil, err
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level: level,
+	}
+	switch strings.ToLower(opts.Format) {
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, handlerOpts)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, handlerOpts)), nil
+	}
+	return nil, ErrorInvalidParameters{
+		Info: fmt.Sprintf("unexpected log format: %q", opts.Format),
+	}
 }
@@ -1,29 +1,80 @@
 package commands
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 func commandOutput(cmd *exec.Cmd, repo string) ([]byte, error) { // TODO: move to another file
 	cmd.Dir = repo
-	out, err := cmd.CombinedOutput()
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	start := time.Now()
+	out, err := cmd.Output()
+	slog.Debug("Git command", "command", cmd.String(), "dir", repo, "duration", time.Since(start))
 	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
 		return nil, ErrorCommandExecution{
-			C: cmd.String(),
-			E: err,
+			C:      cmd.String(),
+			E:      err,
+			Stderr: msg,
+			Class:  failureClass(err, msg),
 		}
 	}
 	return out, nil
 }
 
+// failureClass tells missing repositories and revisions by git messages.
+func failureClass(err error, stderr string) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) && pathErr.Op == "chdir" {
+		return ErrNotRepository
+	}
+
+	msg := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(msg, "not a git repository"):
+		return ErrNotRepository
+	case strings.Contains(msg, "unknown revision"),
+		strings.Contains(msg, "bad revision"),
+		strings.Contains(msg, "bad object"),
+		strings.Contains(msg, "not a valid object name"),
+		strings.Contains(msg, "invalid object name"),
+		strings.Contains(msg, "not a tree object"):
+		return ErrBadRevision
+	}
+	return nil
+}
+
+// GitTree lists the tree of the revision recursively with blob sizes.
 func GitTree(path, revision string) ([]byte, error) {
-	cmd := exec.Command("git", "ls-tree", "-r", revision)
+	cmd := exec.Command("git", "ls-tree", "-r", "-l", revision)
 	return commandOutput(cmd, path)
 }
 
-func GitBlame(repo, path, revision string) ([]byte, error) {
-	cmd := exec.Command("git", "blame", "--porcelain", revision, path)
-	return commandOutput(cmd, repo)
+// blameCommand blames the file at the revision, or the working copy if the revision is empty.
+// Lines older than since, if given, are attributed to boundary commits.
+func blameCommand(path, since, revision string) *exec.Cmd {
+	args := []string{"blame", "--porcelain"}
+	if since != "" {
+		args = append(args, since+".."+revision)
+	} else if revision != "" {
+		args = append(args, revision)
+	}
+	return exec.Command("git", append(args, "--", path)...)
+}
+
+// GitBlameStream streams the porcelain blame of the file.
+func GitBlameStream(repo, path, since, revision string) (io.ReadCloser, error) {
+	return commandStream(blameCommand(path, since, revision), repo)
 }
 
 func GitRevList(repo, path, revision string) ([]byte, error) {
@@ -32,6 +83,9 @@ func GitRevList(repo, path, revision string) ([]byte, error) {
 }
 
 func GitLog(repo, path, revision string) ([]byte, error) {
+	if revision == "" {
+		revision = "HEAD"
+	}
 	cmd := exec.Command("git", "log", "-1", "--pretty=format:'%H\n%an\n%cn'", revision, "--", path)
 	out, err := commandOutput(cmd, repo)
 	if err != nil {
@@ -39,3 +93,154 @@ func GitLog(repo, path, revision string) ([]byte, error) {
 	}
 	return out[1 : len(out)-1], nil
 }
+
+// GitClone clones the source, a bundle or an URL, into a new bare repository.
+func GitClone(source, dest string) ([]byte, error) {
+	cmd := exec.Command("git", "clone", "--quiet", "--bare", source, dest)
+	return commandOutput(cmd, filepath.Dir(dest))
+}
+
+// commandReader streams stdout of a running command, closing it waits for
+// the command and reports its failure.
+type commandReader struct {
+	io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *bytes.Buffer
+	start  time.Time
+}
+
+func (r commandReader) Close() error {
+	_ = r.ReadCloser.Close()
+	err := r.cmd.Wait()
+	slog.Debug("Git command", "command", r.cmd.String(), "dir", r.cmd.Dir, "duration", time.Since(r.start))
+	if err != nil {
+		msg := strings.TrimSpace(r.stderr.String())
+		return ErrorCommandExecution{
+			C:      r.cmd.String(),
+			E:      err,
+			Stderr: msg,
+			Class:  failureClass(err, msg),
+		}
+	}
+	return nil
+}
+
+// commandStream starts the command and returns its stdout. Output is read as
+// it is produced instead of being collected into memory.
+func commandStream(cmd *exec.Cmd, repo string) (io.ReadCloser, error) {
+	cmd.Dir = repo
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	if err = cmd.Start(); err != nil {
+		return nil, ErrorCommandExecution{
+			C:     cmd.String(),
+			E:     err,
+			Class: failureClass(err, ""),
+		}
+	}
+	return commandReader{out, cmd, stderr, start}, nil
+}
+
+// GitBlob streams contents of the blob without loading it into memory.
+func GitBlob(repo, hash string) (io.ReadCloser, error) {
+	cmd := exec.Command("git", "cat-file", "blob", hash)
+	return commandStream(cmd, repo)
+}
+
+// GitLastCommit prints hash, author name, email, time and committer name,
+// email, time of the last commit changing the file, one per line. Without a
+// path the revision itself is printed.
+func GitLastCommit(repo, path, revision string) ([]byte, error) {
+	args := []string{"log", "-1", "--format=%H%n%an%n%ae%n%at%n%cn%n%ce%n%ct", revision}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	cmd := exec.Command("git", args...)
+	return commandOutput(cmd, repo)
+}
+
+// GitIsAncestor tells whether the commit is an ancestor of the revision or
+// the revision itself.
+func GitIsAncestor(repo, commit, revision string) (bool, error) {
+	cmd := exec.Command("git", "merge-base", "--is-ancestor", commit, revision)
+	_, err := commandOutput(cmd, repo)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GitRepositoryState prints whether the path is a bare repository, inside a
+// work tree and a shallow clone, one "true" or "false" per line.
+func GitRepositoryState(repo string) ([]byte, error) {
+	cmd := exec.Command("git", "rev-parse", "--is-bare-repository", "--is-inside-work-tree", "--is-shallow-repository")
+	return commandOutput(cmd, repo)
+}
+
+// GitResolveCommit prints the hash of the commit the revision points to.
+func GitResolveCommit(repo, revision string) ([]byte, error) {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--end-of-options", revision+"^{commit}")
+	return commandOutput(cmd, repo)
+}
+
+// GitDiffNameStatus lists files changed between the revisions with their
+// statuses, detecting renames. Fields are separated by NUL.
+func GitDiffNameStatus(repo, from, to string) ([]byte, error) {
+	cmd := exec.Command("git", "diff", "--name-status", "-z", "-M", "--no-ext-diff", from, to, "--")
+	return commandOutput(cmd, repo)
+}
+
+func GitCommitTime(repo, revision string) ([]byte, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%ct", revision)
+	return commandOutput(cmd, repo)
+}
+
+// GitAuthorTimes lists author (or committer) timestamps and names of the commits
+// reachable from the revision, one line per commit with NUL-separated fields,
+// followed by values of Co-authored-by trailers if asked. git filters by
+// committer dates only, so since narrows the log for committers alone.
+func GitAuthorTimes(repo, revision string, since int64, committer, coauthors bool) ([]byte, error) {
+	if revision == "" {
+		revision = "HEAD"
+	}
+	format := "--format=%at%x00%an"
+	if committer {
+		format = "--format=%ct%x00%cn"
+	}
+	if coauthors {
+		format += "%x00%(trailers:key=Co-authored-by,valueonly,separator=%x00)"
+	}
+	args := []string{"log", format}
+	if committer {
+		args = append(args, fmt.Sprintf("--since=@%d", since))
+	}
+	cmd := exec.Command("git", append(args, revision)...)
+	return commandOutput(cmd, repo)
+}
+
+// GitCommitInfo prints the subject of the commit followed by a NUL and values
+// of Co-authored-by trailers, one per line.
+func GitCommitInfo(repo, hash string) ([]byte, error) {
+	cmd := exec.Command("git", "log", "-1", "--format=%s%x00%(trailers:key=Co-authored-by,valueonly)", hash)
+	return commandOutput(cmd, repo)
+}
+
+// GitCheckIgnore returns NUL-separated paths excluded by gitignore rules.
+func GitCheckIgnore(repo string, paths []string) ([]byte, error) {
+	cmd := exec.Command("git", "check-ignore", "-z", "--stdin")
+	cmd.Stdin = strings.NewReader(strings.Join(paths, "\x00"))
+	out, err := commandOutput(cmd, repo)
+
+	var execErr ErrorCommandExecution
+	var exitErr *exec.ExitError
+	if errors.As(err, &execErr) && errors.As(execErr.E, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil, nil // none of the paths is ignored
+	}
+	return out, err
+}
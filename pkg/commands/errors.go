@@ -1,15 +1,42 @@
 package commands
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// Classes of git failures and outputs, match them with errors.Is.
+var (
+	ErrNotRepository = errors.New("not a git repository")
+	ErrBadRevision   = errors.New("bad revision")
+	ErrShallow       = errors.New("shallow repository")
+	ErrInvalidOutput = errors.New("invalid git output")
+)
 
 type ErrorInvalidGitTreeOutput struct{}
 
 func (e ErrorInvalidGitTreeOutput) Error() string { return "invalid git tree output format" }
 
+func (e ErrorInvalidGitTreeOutput) Is(target error) bool { return target == ErrInvalidOutput }
+
 type ErrorInvalidGitLogOutput struct{}
 
 func (e ErrorInvalidGitLogOutput) Error() string { return "invalid git log output format" }
 
+func (e ErrorInvalidGitLogOutput) Is(target error) bool { return target == ErrInvalidOutput }
+
+type ErrorInvalidGitRevParseOutput struct{}
+
+func (e ErrorInvalidGitRevParseOutput) Error() string { return "invalid git rev-parse output format" }
+
+func (e ErrorInvalidGitRevParseOutput) Is(target error) bool { return target == ErrInvalidOutput }
+
+type ErrorInvalidGitDiffOutput struct{}
+
+func (e ErrorInvalidGitDiffOutput) Error() string { return "invalid git diff output format" }
+
+func (e ErrorInvalidGitDiffOutput) Is(target error) bool { return target == ErrInvalidOutput }
+
 type ErrorInvalidGitBlameOutput struct {
 	Info string
 }
@@ -18,11 +45,58 @@ func (e ErrorInvalidGitBlameOutput) Error() string {
 	return fmt.Sprintf("invalid git blame output format (%s)", e.Info)
 }
 
+func (e ErrorInvalidGitBlameOutput) Is(target error) bool { return target == ErrInvalidOutput }
+
+// ErrorCommandExecution is a failed command with its stderr kept apart from
+// stdout. Class is ErrNotRepository, ErrBadRevision or nil for other failures.
 type ErrorCommandExecution struct {
-	C string
-	E error
+	C      string
+	E      error
+	Stderr string
+	Class  error
 }
 
 func (e ErrorCommandExecution) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("command %q failed (%v): %s", e.C, e.E, e.Stderr)
+	}
 	return fmt.Sprintf("command %q failed (%v)", e.C, e.E)
 }
+
+func (e ErrorCommandExecution) Unwrap() []error {
+	if e.Class != nil {
+		return []error{e.E, e.Class}
+	}
+	return []error{e.E}
+}
+
+type ErrorNotRepository struct {
+	Path string
+}
+
+func (e ErrorNotRepository) Error() string {
+	return fmt.Sprintf("%s is neither a git work tree nor a bare repository", e.Path)
+}
+
+func (e ErrorNotRepository) Is(target error) bool { return target == ErrNotRepository }
+
+type ErrorBadRevision struct {
+	Path     string
+	Revision string
+}
+
+func (e ErrorBadRevision) Error() string {
+	return fmt.Sprintf("revision %q does not name a commit in %s", e.Revision, e.Path)
+}
+
+func (e ErrorBadRevision) Is(target error) bool { return target == ErrBadRevision }
+
+type ErrorShallowRepository struct {
+	Path string
+}
+
+func (e ErrorShallowRepository) Error() string {
+	return fmt.Sprintf("%s is a shallow clone, blame would attribute lines to the oldest fetched commits (fetch the full history or pass --allow-shallow)", e.Path)
+}
+
+func (e ErrorShallowRepository) Is(target error) bool { return target == ErrShallow }
@@ -3,9 +3,11 @@ package files
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/20xygen/git-blame/pkg/commands"
@@ -64,24 +66,50 @@ func (d *Dir) asListHelper(builder *strings.Builder) {
 	}
 }
 
-func gitTreePaths(path, revision string) ([]string, error) {
+// blob is a file of a git tree.
+type blob struct {
+	Hash string
+	Size int64
+}
+
+func gitTreePaths(path, revision string) ([]string, map[string]blob, []Submodule, error) {
 	out, err := commands.GitTree(path, revision)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	var paths []string
+	blobs := make(map[string]blob)
+	var subs []Submodule
 	scanner := bufio.NewScanner(bytes.NewReader(out))
 	for scanner.Scan() {
 		ln := scanner.Text()
 		parts := strings.Split(ln, "\t")
 		if len(parts) != 2 {
-			return nil, commands.ErrorInvalidGitTreeOutput{}
+			return nil, nil, nil, commands.ErrorInvalidGitTreeOutput{}
+		}
+
+		meta := strings.Fields(parts[0])
+		if len(meta) != 4 {
+			return nil, nil, nil, commands.ErrorInvalidGitTreeOutput{}
+		}
+
+		if meta[1] == "commit" {
+			subs = append(subs, Submodule{
+				Path: parts[1],
+				Hash: meta[2],
+			})
+			continue
 		}
+		size, _ := strconv.ParseInt(meta[3], 10, 64)
 		paths = append(paths, parts[1])
+		blobs[parts[1]] = blob{
+			Hash: meta[2],
+			Size: size,
+		}
 	}
 
-	return paths, nil
+	return paths, blobs, subs, nil
 }
 
 func getDirPaths(rootPath string, paths []string) *Dir {
@@ -119,11 +147,23 @@ func getDirPaths(rootPath string, paths []string) *Dir {
 }
 
 func GetDir(path string) (*Dir, error) {
-	var paths *[]string
+	d, _, err := GetDirSubmodules(path)
+	return d, err
+}
+
+// GetDirSubmodules builds the tree of the working copy, leaving out files excluded
+// by gitignore rules. Nested repositories are returned as submodules without a hash.
+// Files absent from HEAD are marked untracked.
+func GetDirSubmodules(path string) (*Dir, []Submodule, error) {
+	var paths []string
+	var subs []Submodule
+	sizes := make(map[string]int64)
 
 	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
 		if err != nil {
-			return err
+			return ErrorWalk{
+				Err: err,
+			}
 		}
 
 		relPath, err := filepath.Rel(path, filePath)
@@ -137,21 +177,117 @@ func GetDir(path string) (*Dir, error) {
 			return nil
 		}
 
-		*paths = append(*paths, relPath)
+		// .git is a file in linked worktrees and checked-out submodules
+		if info.Name() == ".git" {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			if _, err := os.Stat(filepath.Join(filePath, ".git")); err == nil {
+				subs = append(subs, Submodule{
+					Path: relPath,
+				})
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		paths = append(paths, relPath)
+		sizes[relPath] = info.Size()
 		return nil
 	})
+	if err != nil {
+		return nil, nil, err
+	}
 
+	paths, err = notIgnored(path, paths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// HEAD of a repository without commits is unborn, nothing is tracked
+	tracked, _, _, err := gitTreePaths(path, "HEAD")
+	if errors.Is(err, commands.ErrBadRevision) {
+		tracked, err = nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	trackedSet := make(map[string]struct{}, len(tracked))
+	for _, p := range tracked {
+		trackedSet[p] = struct{}{}
+	}
+
+	d := getDirPaths(path, paths)
+	err = d.Walk(func(fl *File) error {
+		rel, err := fl.Rel(path)
+		if err != nil {
+			return err
+		}
+		_, ok := trackedSet[rel]
+		fl.Untracked = !ok
+		fl.Size = sizes[rel]
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return d, subs, nil
+}
+
+func notIgnored(path string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		return paths, nil
+	}
+
+	out, err := commands.GitCheckIgnore(path, paths)
 	if err != nil {
 		return nil, err
 	}
 
-	return getDirPaths(path, *paths), nil
+	ignored := make(map[string]struct{})
+	for _, p := range strings.Split(string(out), "\x00") {
+		ignored[p] = struct{}{}
+	}
+
+	var filtered []string
+	for _, p := range paths {
+		if _, ok := ignored[p]; !ok {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered, nil
 }
 
 func GetDirGit(path, revision string) (*Dir, error) {
-	paths, err := gitTreePaths(path, revision)
+	d, _, err := GetDirGitSubmodules(path, revision)
+	return d, err
+}
+
+// GetDirGitSubmodules builds the tree of the revision without submodule entries
+// and returns the submodules pinned by the revision separately.
+func GetDirGitSubmodules(path, revision string) (*Dir, []Submodule, error) {
+	paths, blobs, subs, err := gitTreePaths(path, revision)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	d := getDirPaths(path, paths)
+	err = d.Walk(func(fl *File) error {
+		rel, err := fl.Rel(path)
+		if err != nil {
+			return err
+		}
+		fl.Hash = blobs[rel].Hash
+		fl.Size = blobs[rel].Size
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	return getDirPaths(path, paths), nil
+	return d, subs, nil
 }
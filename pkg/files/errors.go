@@ -10,6 +10,8 @@ func (e ErrorWalk) Error() string {
 	return fmt.Sprintf("walking the directory failed (%v)", e.Err)
 }
 
+func (e ErrorWalk) Unwrap() error { return e.Err }
+
 type ErrorRelativePath struct {
 	E error
 }
@@ -17,3 +19,5 @@ type ErrorRelativePath struct {
 func (e ErrorRelativePath) Error() string {
 	return fmt.Sprintf("getting relative path failed (%v)", e.E)
 }
+
+func (e ErrorRelativePath) Unwrap() error { return e.E }
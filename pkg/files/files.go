@@ -1,13 +1,33 @@
 package files
 
 import (
+	"bytes"
+	"io"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
 type LangInfo struct {
 	ExtToLang map[string]string
 	LangToExs map[string][]string
+	Comments  map[string]CommentSyntax // by language, only for known syntaxes
+}
+
+// CommentSyntax lists line comment prefixes and block comment delimiters of a language.
+type CommentSyntax struct {
+	Line  []string    `json:"line"`
+	Block [][2]string `json:"block"`
+}
+
+// CommentSyntax returns the comment syntax of the file language, if known.
+func (f *File) CommentSyntax(info *LangInfo) (CommentSyntax, bool) {
+	if info == nil || info.Comments == nil {
+		return CommentSyntax{}, false
+	}
+	syntax, ok := info.Comments[f.Lang(info)]
+	return syntax, ok
 }
 
 type Entity interface {
@@ -15,8 +35,11 @@ type Entity interface {
 }
 
 type File struct {
-	Name string
-	Dad  *Dir
+	Name      string
+	Dad       *Dir
+	Untracked bool   // absent from HEAD, only set for working copy trees
+	Hash      string // blob hash, empty for working copy trees
+	Size      int64  // bytes
 }
 
 func (f *File) Path() string {
@@ -73,8 +96,16 @@ func (d *Dir) Path() string {
 	return d.Name
 }
 
+// Walk calls fn for every file of the tree in lexical order.
 func (d *Dir) Walk(fn func(*File) error) error {
-	for _, kid := range d.Kids {
+	names := make([]string, 0, len(d.Kids))
+	for name := range d.Kids {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		kid := d.Kids[name]
 		if fl, ok := kid.(*File); ok {
 			err := fn(fl)
 			if err != nil {
@@ -89,3 +120,39 @@ func (d *Dir) Walk(fn func(*File) error) error {
 	}
 	return nil
 }
+
+// Submodule is a gitlink entry of a tree pinned to a commit of another repository.
+type Submodule struct {
+	Path string
+	Hash string
+}
+
+// CheckedOut reports whether the submodule has a work tree inside the root repository.
+func (s Submodule) CheckedOut(root string) bool {
+	_, err := os.Stat(filepath.Join(root, s.Path, ".git"))
+	return err == nil
+}
+
+// CountLines counts lines of the contents, the last one may lack a newline.
+func CountLines(r io.Reader) (int, error) {
+	buf := make([]byte, 64*1024)
+	lines := 0
+	last := byte('\n')
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, nil
+}
@@ -6,10 +6,18 @@ import (
 	"strings"
 )
 
+// Uncommitted lines are attributed by git blame to this synthetic commit.
+const (
+	NotCommittedHash   = "0000000000000000000000000000000000000000"
+	NotCommittedAuthor = "Not Committed Yet"
+	NotCommittedMail   = "<not.committed.yet>"
+)
+
 type Commit struct {
 	Hash     string
 	LinesNum int
 	Meta     map[string]string
+	Boundary bool // boundary of the blamed range, root commits are boundaries too
 }
 
 func (c *Commit) String() string {
@@ -26,6 +34,16 @@ type Line struct {
 type BlameOutput struct {
 	Commits map[string]*Commit
 	Lines   []*Line
+	Size    int // bytes of the porcelain output
+}
+
+// LinesNum returns the number of blamed lines, also when Lines are not kept.
+func (b *BlameOutput) LinesNum() int {
+	n := 0
+	for _, com := range b.Commits {
+		n += com.LinesNum
+	}
+	return n
 }
 
 func (b *BlameOutput) String() string {
@@ -2,15 +2,19 @@ package parsing
 
 import (
 	"bufio"
-	"bytes"
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/20xygen/git-blame/pkg/commands"
 )
 
-func parseEmpty(repo, path, revision string, bo *BlameOutput) error {
+// parseEmpty credits an empty file to its last commit, a boundary one if the
+// commit is older than the blamed range.
+func parseEmpty(repo, path, since, revision string, bo *BlameOutput) error {
 	log, err := commands.GitLog(repo, path, revision)
 	if err != nil {
 		return err
@@ -32,28 +36,62 @@ func parseEmpty(repo, path, revision string, bo *BlameOutput) error {
 
 	com.Meta["author"] = author
 	com.Meta["committer"] = committer
+	if since != "" {
+		com.Boundary, err = commands.GitIsAncestor(repo, hash, since)
+		if err != nil {
+			return err
+		}
+	}
 	bo.Commits[hash] = &com
 
 	return nil
 }
 
-func ParseBlame(repo, path, revision string) (*BlameOutput, error) {
-	out, err := commands.GitBlame(repo, path, revision)
+// lineReader reads lines of any length and counts bytes read.
+type lineReader struct {
+	r    *bufio.Reader
+	size int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReaderSize(r, 64*1024)}
+}
+
+// next returns the line without the line break, false at the end of input.
+func (lr *lineReader) next() (string, bool, error) {
+	line, err := lr.r.ReadString('\n')
+	lr.size += len(line)
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err == io.EOF {
+		return "", false, nil
+	}
 	if err != nil {
-		fmt.Println(repo, path)
-		return nil, err
+		return "", false, err
 	}
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), true, nil
+}
 
+// parsePorcelain aggregates lines per commit as the output is read. Lines
+// with their contents are kept only if asked, so statistics of huge files
+// don't hold them in memory.
+func parsePorcelain(r io.Reader, keepLines bool) (*BlameOutput, error) {
 	bo := BlameOutput{
 		Commits: make(map[string]*Commit),
 		Lines:   make([]*Line, 0),
 	}
 
-	empty := true
-	scanner := bufio.NewScanner(bytes.NewReader(out))
-	for scanner.Scan() {
-		empty = false
-		firstLine := scanner.Text()
+	lr := newLineReader(r)
+	for {
+		firstLine, ok, err := lr.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
 
 		if firstLine == " <nil>" {
 			continue
@@ -83,14 +121,19 @@ func ParseBlame(repo, path, revision string) (*BlameOutput, error) {
 			bo.Commits[hash] = com
 		}
 
-		if !scanner.Scan() {
+		nextLine, ok, err := lr.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
 			return nil, commands.ErrorInvalidGitBlameOutput{
 				Info: fmt.Sprintf("invalid block after %v (empty block)", parts),
 			}
 		}
-		nextLine := scanner.Text()
-		for nextLine[0] != '\t' {
-			if nextLine != "boundary" {
+		for !strings.HasPrefix(nextLine, "\t") {
+			if nextLine == "boundary" {
+				com.Boundary = true
+			} else {
 				params := strings.SplitN(nextLine, " ", 2)
 				if len(params) != 2 {
 					return nil, commands.ErrorInvalidGitBlameOutput{
@@ -100,32 +143,113 @@ func ParseBlame(repo, path, revision string) (*BlameOutput, error) {
 				com.Meta[params[0]] = params[1]
 			}
 
-			if !scanner.Scan() {
+			nextLine, ok, err = lr.next()
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
 				return nil, commands.ErrorInvalidGitBlameOutput{
 					Info: fmt.Sprintf("invalid block after %v (block with no content)", parts),
 				}
 			}
-			nextLine = scanner.Text()
 		}
 
-		bo.Lines = append(bo.Lines, &Line{
-			Com:     com,
-			PrevPos: prev,
-			CurPos:  cur,
-			Content: nextLine[1:],
-		})
+		com.LinesNum++
+		if keepLines {
+			bo.Lines = append(bo.Lines, &Line{
+				Com:     com,
+				PrevPos: prev,
+				CurPos:  cur,
+				Content: nextLine[1:],
+			})
+		}
+	}
+
+	bo.Size = lr.size
+	return &bo, nil
+}
+
+// ParseBlame blames the file keeping every line with its contents.
+func ParseBlame(repo, path, since, revision string) (*BlameOutput, error) {
+	return parseBlame(repo, path, since, revision, true)
+}
+
+// CountBlame blames the file counting lines per commit only, Lines of the
+// output are empty.
+func CountBlame(repo, path, since, revision string) (*BlameOutput, error) {
+	return parseBlame(repo, path, since, revision, false)
+}
+
+func parseBlame(repo, path, since, revision string, keepLines bool) (*BlameOutput, error) {
+	out, err := commands.GitBlameStream(repo, path, since, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	bo, err := parsePorcelain(out, keepLines)
+	if err != nil {
+		_ = out.Close()
+		return nil, err
+	}
+	if err = out.Close(); err != nil {
+		return nil, err
 	}
 
-	if empty {
-		err := parseEmpty(repo, path, revision, &bo)
+	if bo.Size == 0 {
+		err = parseEmpty(repo, path, since, revision, bo)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-	} else {
-		for _, ln := range bo.Lines {
-			ln.Com.LinesNum++
+	return bo, nil
+}
+
+// ParseUntracked attributes every line of a file unknown to git to the
+// synthetic uncommitted commit, the same way blame does for modified lines.
+func ParseUntracked(path string) (*BlameOutput, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	com := &Commit{
+		Hash: NotCommittedHash,
+		Meta: map[string]string{
+			"author":         NotCommittedAuthor,
+			"author-mail":    NotCommittedMail,
+			"author-time":    now,
+			"committer":      NotCommittedAuthor,
+			"committer-mail": NotCommittedMail,
+			"committer-time": now,
+		},
+	}
+
+	bo := BlameOutput{
+		Commits: map[string]*Commit{com.Hash: com},
+		Lines:   make([]*Line, 0),
+	}
+
+	lr := newLineReader(f)
+	var pos uint64
+	for {
+		line, ok, err := lr.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
 		}
+		pos++
+		bo.Lines = append(bo.Lines, &Line{
+			Com:     com,
+			PrevPos: pos,
+			CurPos:  pos,
+			Content: line,
+		})
+		com.LinesNum++
 	}
 
 	return &bo, nil
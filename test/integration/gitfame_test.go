@@ -2,11 +2,13 @@ package integration
 
 import (
 	"bytes"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -41,27 +43,70 @@ func TestGitFame(t *testing.T) {
 		tc := ReadTestCase(t, filepath.Join(testsDir, dir))
 
 		t.Run(dir+"/"+tc.Name, func(t *testing.T) {
-			dir, err := os.MkdirTemp("", "gitfame-")
+			tmp, err := os.MkdirTemp("", "gitfame-")
 			require.NoError(t, err)
-			defer func() { _ = os.RemoveAll(dir) }()
+			defer func() { _ = os.RemoveAll(tmp) }()
 
-			args := []string{"--repository", dir}
-			args = append(args, tc.Args...)
+			// the clone is named after the bundle, so repository names are stable
+			name := strings.TrimSuffix(tc.Bundle, ".bundle")
+			if name == "" {
+				name = "empty"
+			}
+			dir := filepath.Join(tmp, name)
 
-			Unbundle(t, filepath.Join(bundlesDir, tc.Bundle), dir)
+			var args []string
+			if !tc.NoRepository {
+				args = []string{"--repository", dir}
+			}
+			// $TMP is the temporary directory of the test, e.g. for output files
+			for _, arg := range tc.Args {
+				args = append(args, strings.ReplaceAll(arg, "$TMP", tmp))
+			}
+
+			if tc.Bundle == "" {
+				Init(t, dir)
+			} else if tc.Bare {
+				BareClone(t, filepath.Join(bundlesDir, tc.Bundle), dir)
+			} else if tc.Linked {
+				main := dir + "-main"
+				Unbundle(t, filepath.Join(bundlesDir, tc.Bundle), main)
+				AddWorktree(t, main, dir)
+			} else if tc.Shallow {
+				full := dir + "-full"
+				Unbundle(t, filepath.Join(bundlesDir, tc.Bundle), full)
+				ShallowClone(t, full, dir)
+			} else {
+				Unbundle(t, filepath.Join(bundlesDir, tc.Bundle), dir)
+			}
+			if tc.Submodules {
+				UpdateSubmodules(t, dir)
+			}
+			for path, contents := range tc.Write {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, path), []byte(contents), 0644))
+			}
 			headRef := GetHEADRef(t, dir)
 
+			var stderr bytes.Buffer
 			cmd := exec.Command(binary, args...)
-			cmd.Stderr = os.Stderr
+			cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
 
 			output, err := cmd.Output()
+			for _, s := range tc.Stderr {
+				require.Contains(t, stderr.String(), s)
+			}
+			for _, s := range tc.NoStderr {
+				require.NotContains(t, stderr.String(), s)
+			}
 			if !tc.Error {
 				require.NoError(t, err)
 				CompareResults(t, tc.Expected, output, tc.Format)
 			} else {
 				require.Error(t, err)
-				_, ok := err.(*exec.ExitError)
+				exitErr, ok := err.(*exec.ExitError)
 				require.True(t, ok)
+				if tc.ExitCode != 0 {
+					require.Equal(t, tc.ExitCode, exitErr.ExitCode())
+				}
 			}
 
 			newHEADRef := GetHEADRef(t, dir)
@@ -114,11 +159,20 @@ func ReadTestCase(t *testing.T, path string) *TestCase {
 }
 
 type TestDescription struct {
-	Name   string   `yaml:"name"`
-	Args   []string `yaml:"args"`
-	Bundle string   `yaml:"bundle"`
-	Error  bool     `yaml:"error"`
-	Format string   `yaml:"format,omitempty"`
+	Name         string            `yaml:"name"`
+	Args         []string          `yaml:"args"`
+	Bundle       string            `yaml:"bundle"`
+	Submodules   bool              `yaml:"submodules,omitempty"`
+	Shallow      bool              `yaml:"shallow,omitempty"`         // clone the bundle with a history of one commit
+	Bare         bool              `yaml:"bare,omitempty"`            // clone the bundle without a working copy
+	Linked       bool              `yaml:"linked_worktree,omitempty"` // run in a worktree linked to the clone
+	NoRepository bool              `yaml:"no_repository,omitempty"`   // don't pass the clone, e.g. with manifests
+	Write        map[string]string `yaml:"write,omitempty"`           // files written to the working copy
+	Stderr       []string          `yaml:"stderr_contains,omitempty"`
+	NoStderr     []string          `yaml:"stderr_not_contains,omitempty"`
+	Error        bool              `yaml:"error"`
+	ExitCode     int               `yaml:"exit_code,omitempty"`
+	Format       string            `yaml:"format,omitempty"`
 }
 
 func ReadTestDescription(t *testing.T, path string) *TestDescription {
@@ -133,6 +187,14 @@ func ReadTestDescription(t *testing.T, path string) *TestDescription {
 	return &desc
 }
 
+// Init creates a repository without commits.
+func Init(t *testing.T, dst string) {
+	t.Helper()
+
+	cmd := exec.Command("git", "init", dst)
+	require.NoError(t, cmd.Run())
+}
+
 func Unbundle(t *testing.T, src, dst string) {
 	t.Helper()
 
@@ -140,6 +202,43 @@ func Unbundle(t *testing.T, src, dst string) {
 	require.NoError(t, cmd.Run())
 }
 
+// BareClone clones the repository without a work tree.
+func BareClone(t *testing.T, src, dst string) {
+	t.Helper()
+
+	cmd := exec.Command("git", "clone", "--bare", src, dst)
+	require.NoError(t, cmd.Run())
+}
+
+// AddWorktree checks out HEAD of the repository into a linked worktree, where
+// .git is a file.
+func AddWorktree(t *testing.T, repo, dst string) {
+	t.Helper()
+
+	cmd := exec.Command("git", "worktree", "add", "--detach", dst)
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+}
+
+// ShallowClone clones the repository with the last commit only.
+func ShallowClone(t *testing.T, src, dst string) {
+	t.Helper()
+
+	abs, err := filepath.Abs(src)
+	require.NoError(t, err)
+	cmd := exec.Command("git", "clone", "--depth", "1", "file://"+abs, dst)
+	require.NoError(t, cmd.Run())
+}
+
+// UpdateSubmodules checks out submodules, their URLs point to sibling bundles.
+func UpdateSubmodules(t *testing.T, path string) {
+	t.Helper()
+
+	cmd := exec.Command("git", "-c", "protocol.file.allow=always", "submodule", "update", "--init")
+	cmd.Dir = path
+	require.NoError(t, cmd.Run())
+}
+
 func CompareResults(t *testing.T, expected, actual []byte, format string) {
 	t.Helper()
 
@@ -177,14 +276,13 @@ func ParseJSONLines(data []byte) [][]byte {
 	return bytes.Split(bytes.TrimSpace(data), []byte("\n"))
 }
 
+// GetHEADRef returns the commit of HEAD, empty if it is unborn.
 func GetHEADRef(t *testing.T, path string) string {
 	t.Helper()
 
-	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd := exec.Command("git", "rev-parse", "--verify", "-q", "HEAD")
 	cmd.Dir = path
 
-	out, err := cmd.Output()
-	require.NoError(t, err)
-
+	out, _ := cmd.Output()
 	return string(out)
 }